@@ -0,0 +1,141 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+// archiveSeparator separates the path of an archive from the name of one of
+// its members, as in "results.tar.gz::linux/bench.out".
+const archiveSeparator = "::"
+
+// splitArchive returns the path of the archive and the member name, which may
+// be a glob pattern, of an argument naming members of an archive.
+func splitArchive(file string) (string, string, bool) {
+	var start int
+	if isURL(file) {
+		// Skip the host, which may be an IPv6 address, such as [::1].
+		start = strings.Index(file, "://") + 3
+		if i := strings.IndexByte(file[start:], '/'); i >= 0 {
+			start += i
+		} else {
+			return "", "", false
+		}
+	}
+	i := strings.Index(file[start:], archiveSeparator)
+	if i < 0 {
+		return "", "", false
+	}
+	i += start
+	return file[:i], file[i+len(archiveSeparator):], true
+}
+
+// withArchiveMembers invokes callback with the name and contents of each
+// member of the tar or zip archive whose name matches pattern, in the order
+// they appear in the archive, without extracting them. The format of the
+// archive is determined by its extension, and tar archives may be compressed
+// with gzip.
+func withArchiveMembers(ctx context.Context, archive, pattern string, callback func(string, io.Reader) error) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("cannot parse archive member as glob pattern: %q", pattern)
+	}
+	var found bool
+	matches := func(name string) bool {
+		ok, _ := path.Match(pattern, strings.TrimPrefix(name, "./"))
+		found = found || ok
+		return ok
+	}
+
+	var err error
+	switch lower := strings.ToLower(archive); {
+	case strings.HasSuffix(lower, ".zip"):
+		err = withOpenFile(ctx, archive, func(r io.Reader) error {
+			// Zip archives are read from their end, so are held in memory
+			// only when they are not files that may be read at any offset.
+			ra, size, ok := readerAt(r)
+			if !ok {
+				buf, err := ioutil.ReadAll(r)
+				if err != nil {
+					return err
+				}
+				ra, size = bytes.NewReader(buf), int64(len(buf))
+			}
+			zr, err := zip.NewReader(ra, size)
+			if err != nil {
+				return err
+			}
+			for _, f := range zr.File {
+				if f.FileInfo().IsDir() || !matches(f.Name) {
+					continue
+				}
+				rc, err := f.Open()
+				if err != nil {
+					return err
+				}
+				err = callback(f.Name, rc)
+				rc.Close()
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case strings.HasSuffix(lower, ".tar"), strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		err = withOpenFile(ctx, archive, func(r io.Reader) error {
+			if !strings.HasSuffix(lower, ".tar") {
+				gr, err := gzip.NewReader(r)
+				if err != nil {
+					return err
+				}
+				defer gr.Close()
+				r = gr
+			}
+			tr := tar.NewReader(r)
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if hdr.Typeflag != tar.TypeReg || !matches(hdr.Name) {
+					continue
+				}
+				if err = callback(hdr.Name, tr); err != nil {
+					return err
+				}
+			}
+		})
+	default:
+		return fmt.Errorf("cannot determine archive format from extension: %q", archive)
+	}
+
+	if err == nil && !found {
+		err = fmt.Errorf("cannot find archive member: %q", archive+archiveSeparator+pattern)
+	}
+	return err
+}
+
+// readerAt returns r as an io.ReaderAt, along with its size, when r is a
+// mapped file or a regular file, either of which may be read at any offset.
+func readerAt(r io.Reader) (io.ReaderAt, int64, bool) {
+	switch f := r.(type) {
+	case *mappedFile:
+		return f, f.Size(), true
+	case *os.File:
+		if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() {
+			return f, fi.Size(), true
+		}
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// checkFiles writes to iow the name of each file in files whose contents
+// differ from how this program would align them, and returns true when there
+// is at least one such file. When files is empty, it checks standard input.
+func checkFiles(ctx context.Context, files []string, iow io.Writer) (bool, error) {
+	headerLines := optHeaderLines // each file has its own header lines
+	var unaligned bool
+
+	err := forEachFile(ctx, files, func(name string, r io.Reader, _ io.Writer) error {
+		original, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		optHeaderLines = headerLines
+		var aligned bytes.Buffer
+		if err = processFile(bytes.NewReader(original), &aligned, name); err != nil {
+			return err
+		}
+		if !bytes.Equal(original, aligned.Bytes()) {
+			fmt.Fprintf(iow, "%s\n", name)
+			unaligned = true
+		}
+		return nil
+	})
+
+	return unaligned, err
+}
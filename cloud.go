@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// isCloudURI returns true when path names an object in Amazon S3 or Google
+// Cloud Storage rather than a file.
+func isCloudURI(path string) bool {
+	return strings.HasPrefix(path, "s3://") || strings.HasPrefix(path, "gs://")
+}
+
+// openCloud fetches the object named by uri, such as s3://bucket/key or
+// gs://bucket/key, with the credentials found the way the providers' own
+// tools find them, or anonymously when there are none. Canceling ctx abandons
+// the requests.
+//
+// Only static credentials are found: keys in the environment or the shared
+// credentials file of AWS, and the user or service account keys of the
+// application default credentials of Google Cloud. Instance and task roles,
+// SSO, role_arn and source_profile, credential_process, the Compute Engine
+// metadata server, and external account credentials are not supported.
+func openCloud(ctx context.Context, uri string) (io.ReadCloser, error) {
+	i := strings.IndexByte(uri[5:], '/')
+	if i <= 0 || 5+i+1 == len(uri) {
+		return nil, fmt.Errorf("cannot parse object URI: %q", uri)
+	}
+	bucket, key := uri[5:5+i], uri[5+i+1:]
+
+	var req *http.Request
+	var err error
+	if strings.HasPrefix(uri, "s3://") {
+		req, err = s3Request(bucket, key)
+	} else {
+		req, err = gcsRequest(ctx, bucket, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fetch(req.WithContext(ctx), uri)
+}
+
+// s3EmptyHash is the SHA-256 digest of an empty request body.
+const s3EmptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// s3Request returns a request for the S3 object, signed with AWS Signature
+// Version 4 when credentials are found in the environment or in the shared
+// credentials file. The region is likewise found in the environment or in
+// the shared config file.
+func s3Request(bucket, key string) (*http.Request, error) {
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	section := "profile " + profile
+	if profile == "default" {
+		section = profile
+	}
+
+	region := firstNonEmpty(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), awsFileValue("AWS_CONFIG_FILE", "config", section, "region"), "us-east-1")
+
+	var segments []string
+	for _, segment := range strings.Split(key, "/") {
+		segments = append(segments, awsEscape(segment))
+	}
+	path := strings.Join(segments, "/")
+
+	var u string
+	switch endpoint := os.Getenv("AWS_ENDPOINT_URL"); {
+	case endpoint != "":
+		u = strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + path
+	case strings.Contains(bucket, "."):
+		// Names with dots do not match the wildcard certificate of the
+		// virtual hosted endpoint.
+		u = "https://s3." + region + ".amazonaws.com/" + bucket + "/" + path
+	default:
+		u = "https://" + bucket + ".s3." + region + ".amazonaws.com/" + path
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	id, secret, token := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN")
+	if id == "" || secret == "" {
+		id = awsFileValue("AWS_SHARED_CREDENTIALS_FILE", "credentials", profile, "aws_access_key_id")
+		secret = awsFileValue("AWS_SHARED_CREDENTIALS_FILE", "credentials", profile, "aws_secret_access_key")
+		token = awsFileValue("AWS_SHARED_CREDENTIALS_FILE", "credentials", profile, "aws_session_token")
+	}
+	if id == "" || secret == "" {
+		return req, nil // public objects need no signature
+	}
+
+	req.Header.Set("X-Amz-Content-Sha256", s3EmptyHash)
+	if token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	signV4(req, "s3", region, id, secret, time.Now())
+	return req, nil
+}
+
+// signV4 signs req, whose body is empty, with AWS Signature Version 4 for
+// service in region, as of now, adding its X-Amz-Date and Authorization
+// headers. Every header of req, along with its host, is signed.
+func signV4(req *http.Request, service, region, id, secret string, now time.Time) {
+	now = now.UTC()
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = req.Header.Get(name)
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + strings.Join(strings.Fields(headers[name]), " ") + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	request := strings.Join([]string{req.Method, req.URL.EscapedPath(), "", canonical.String(), signed, s3EmptyHash}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	digest := sha256.Sum256([]byte(request))
+	toSign := "AWS4-HMAC-SHA256\n" + now.Format("20060102T150405Z") + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+
+	k := hmacSHA256([]byte("AWS4"+secret), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		k = hmacSHA256(k, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(k, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", id, scope, signed, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of message with key.
+func hmacSHA256(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	io.WriteString(mac, message)
+	return mac.Sum(nil)
+}
+
+// awsEscape returns segment with every byte other than unreserved characters
+// percent encoded, as AWS signatures require.
+func awsEscape(segment string) string {
+	var sb strings.Builder
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~", c) >= 0 {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// awsFileValue returns the value of key in section of the AWS file named by
+// the environment variable, or the file with the specified name in ~/.aws
+// when the variable is not set. It returns the empty string when there is no
+// such value.
+func awsFileValue(variable, name, section, key string) string {
+	path := os.Getenv(variable)
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		path = filepath.Join(home, ".aws", name)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var current string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", line[0] == '#', line[0] == ';':
+		case line[0] == '[' && line[len(line)-1] == ']':
+			current = strings.TrimSpace(line[1 : len(line)-1])
+		case current == section:
+			if i := strings.IndexByte(line, '='); i > 0 && strings.TrimSpace(line[:i]) == key {
+				return strings.TrimSpace(line[i+1:])
+			}
+		}
+	}
+	return ""
+}
+
+// firstNonEmpty returns the first of values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// gcsRequest returns a request for the Google Cloud Storage object,
+// authorized with an access token obtained with the application default
+// credentials, when there are any.
+func gcsRequest(ctx context.Context, bucket, key string) (*http.Request, error) {
+	token, err := gcsToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain Google Cloud access token: %s", err)
+	}
+	if token == "" {
+		// Public objects need no authorization.
+		return http.NewRequest(http.MethodGet, "https://storage.googleapis.com/"+bucket+"/"+(&url.URL{Path: key}).EscapedPath(), nil)
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://storage.googleapis.com/storage/v1/b/"+url.PathEscape(bucket)+"/o/"+url.PathEscape(key)+"?alt=media", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req, nil
+}
+
+// gcsCredentials holds the fields of the application default credentials
+// files, for either a user or a service account.
+type gcsCredentials struct {
+	Type         string `json:"type"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// gcsScope is the scope of the access tokens requested for reading objects.
+const gcsScope = "https://www.googleapis.com/auth/devstorage.read_only"
+
+// gcsToken returns an access token obtained with the application default
+// credentials, which are found in the file named by the
+// GOOGLE_APPLICATION_CREDENTIALS environment variable, or else in the file
+// written by 'gcloud auth application-default login'. It returns the empty
+// string when there are no credentials.
+func gcsToken(ctx context.Context) (string, error) {
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		dir := os.Getenv("APPDATA")
+		if runtime.GOOS != "windows" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", nil
+			}
+			dir = filepath.Join(home, ".config")
+		}
+		path = filepath.Join(dir, "gcloud", "application_default_credentials.json")
+		if _, err := os.Stat(path); err != nil {
+			return "", nil
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	var creds gcsCredentials
+	if err = json.NewDecoder(f).Decode(&creds); err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	tokenURI := firstNonEmpty(creds.TokenURI, "https://oauth2.googleapis.com/token")
+	switch creds.Type {
+	case "authorized_user":
+		form.Set("grant_type", "refresh_token")
+		form.Set("client_id", creds.ClientID)
+		form.Set("client_secret", creds.ClientSecret)
+		form.Set("refresh_token", creds.RefreshToken)
+	case "service_account":
+		assertion, err := gcsAssertion(creds, tokenURI, time.Now())
+		if err != nil {
+			return "", err
+		}
+		form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		form.Set("assertion", assertion)
+	default:
+		return "", fmt.Errorf("cannot use credentials of type: %q", creds.Type)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New(resp.Status)
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	return body.AccessToken, nil
+}
+
+// gcsAssertion returns a JSON Web Token issued at now, and signed with the
+// private key of the service account, which is exchanged for an access token
+// at tokenURI.
+func gcsAssertion(creds gcsCredentials, tokenURI string, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return "", errors.New("cannot decode private key of service account")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("cannot use private key of service account other than RSA")
+	}
+
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   creds.ClientEmail,
+		"scope": gcsScope,
+		"aud":   tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Unix() + 3600,
+	})
+	if err != nil {
+		return "", err
+	}
+	encoding := base64.RawURLEncoding
+	unsigned := encoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + encoding.EncodeToString(signature), nil
+}
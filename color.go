@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// sgrReset is the Select Graphic Rendition sequence that restores the default
+// rendition of the terminal.
+const sgrReset = "\x1b[0m"
+
+// SGR sequences for commonly used colors.
+const (
+	sgrGreen  = "\x1b[32m"
+	sgrRed    = "\x1b[31m"
+	sgrStripe = "\x1b[48;5;236m" // dark gray background
+)
+
+// useColor is true when output may include SGR sequences.
+var useColor bool
+
+// Color policies determine when output may include SGR sequences.
+const (
+	colorAlways = "always" // regardless of where output is written
+	colorAuto   = "auto"   // when writing to a terminal, absent NO_COLOR
+	colorNever  = "never"  // under no circumstances
+)
+
+// colorPolicy returns whether output may include SGR sequences under the
+// specified color policy. As described at https://no-color.org, a non-empty
+// NO_COLOR environment variable disables color unless it is explicitly
+// requested.
+func colorPolicy(policy string) bool {
+	switch policy {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	default:
+		return os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+	}
+}
+
+// sgrAttributes maps the names of text attributes to their SGR parameters.
+var sgrAttributes = map[string]int{
+	"bold":      1,
+	"dim":       2,
+	"italic":    3,
+	"underline": 4,
+	"blink":     5,
+	"reverse":   7,
+}
+
+// sgrColors maps the names of colors to their SGR foreground parameters. The
+// corresponding background parameter is 10 greater.
+var sgrColors = map[string]int{
+	"black":   30,
+	"red":     31,
+	"green":   32,
+	"yellow":  33,
+	"blue":    34,
+	"magenta": 35,
+	"cyan":    36,
+	"white":   37,
+}
+
+// parseStyle returns the SGR sequence for the comma separated list of
+// attribute and color names in s, such as "bold,red". Background colors are
+// named with a "bg-" prefix, such as "bg-blue".
+func parseStyle(s string) (string, error) {
+	var params []string
+
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if p, ok := sgrAttributes[name]; ok {
+			params = append(params, strconv.Itoa(p))
+		} else if p, ok := sgrColors[name]; ok {
+			params = append(params, strconv.Itoa(p))
+		} else if p, ok := sgrColors[strings.TrimPrefix(name, "bg-")]; ok && strings.HasPrefix(name, "bg-") {
+			params = append(params, strconv.Itoa(p+10))
+		} else {
+			return "", fmt.Errorf("cannot parse style name: %q", name)
+		}
+	}
+
+	return "\x1b[" + strings.Join(params, ";") + "m", nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// colorRule colors the fields of a column which satisfy a condition.
+type colorRule struct {
+	column  int            // zero-based index of the column tested
+	op      string         // comparison operator, or "=~" or "!~" for patterns
+	operand string         // text compared with each field
+	pattern *regexp.Regexp // compiled operand for the pattern operators
+	style   string         // SGR sequence for matching fields
+}
+
+// colorRuleOperators lists the operators of color rules, with each operator
+// preceding those which are its prefixes.
+var colorRuleOperators = []string{"=~", "!~", "==", "!=", "<=", ">=", "<", ">", "="}
+
+// parseColorRule returns the color rule described by s, such as "3>1000:red"
+// or "5=~FAIL:bold,yellow": a column number, an operator, an operand, and
+// following the final colon, a style.
+func parseColorRule(s string) (colorRule, error) {
+	var cr colorRule
+
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return cr, fmt.Errorf("cannot parse color rule without style: %q", s)
+	}
+	style, err := parseStyle(s[i+1:])
+	if err != nil {
+		return cr, err
+	}
+	cr.style = style
+	condition := s[:i]
+
+	j := strings.IndexAny(condition, "=!<>")
+	if j < 0 {
+		return cr, fmt.Errorf("cannot parse color rule without operator: %q", s)
+	}
+	if cr.column, err = parseColumnNumber(strings.TrimSpace(condition[:j])); err != nil {
+		return cr, err
+	}
+	for _, op := range colorRuleOperators {
+		if strings.HasPrefix(condition[j:], op) {
+			cr.op = op
+			break
+		}
+	}
+	if cr.op == "" {
+		return cr, fmt.Errorf("cannot parse color rule operator: %q", s)
+	}
+	if cr.op == "=" {
+		cr.op = "=="
+		cr.operand = condition[j+1:]
+	} else {
+		cr.operand = condition[j+len(cr.op):]
+	}
+
+	if cr.op == "=~" || cr.op == "!~" {
+		if cr.pattern, err = regexp.Compile(cr.operand); err != nil {
+			return cr, err
+		}
+	}
+
+	return cr, nil
+}
+
+// matches returns true when field, found in the column with index i,
+// satisfies the condition of the rule. Fields are compared numerically when
+// both the field and the operand are numbers, and lexically otherwise.
+func (cr colorRule) matches(i int, field string) bool {
+	if i != cr.column {
+		return false
+	}
+	switch cr.op {
+	case "=~":
+		return cr.pattern.MatchString(field)
+	case "!~":
+		return !cr.pattern.MatchString(field)
+	}
+
+	var c int
+	fv, fok := parseNumber(field)
+	ov, ook := parseNumber(cr.operand)
+	if fok && ook {
+		switch {
+		case fv < ov:
+			c = -1
+		case fv > ov:
+			c = 1
+		}
+	} else {
+		c = strings.Compare(field, cr.operand)
+	}
+
+	switch cr.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// columnRange is an inclusive range of zero-based column indexes. A negative
+// hi means the range extends through the final column.
+type columnRange struct {
+	lo, hi int
+}
+
+// columnList is an ordered list of column ranges, as provided on the command
+// line using one-based column numbers, such as "1,3-5,8", or "7-" for the
+// seventh and all subsequent columns.
+type columnList []columnRange
+
+// parseColumnList returns the columnList described by s.
+func parseColumnList(s string) (columnList, error) {
+	var cl columnList
+
+	for _, item := range strings.Split(s, ",") {
+		var lo, hi int
+		var err error
+
+		item = strings.TrimSpace(item)
+		if i := strings.IndexByte(item, '-'); i >= 0 {
+			if lo, err = parseColumnNumber(item[:i]); err != nil {
+				return nil, err
+			}
+			if item[i+1:] == "" {
+				hi = -1 // open ended range
+			} else if hi, err = parseColumnNumber(item[i+1:]); err != nil {
+				return nil, err
+			} else if hi < lo {
+				return nil, fmt.Errorf("cannot use descending column range: %q", item)
+			}
+		} else {
+			if lo, err = parseColumnNumber(item); err != nil {
+				return nil, err
+			}
+			hi = lo
+		}
+		cl = append(cl, columnRange{lo: lo, hi: hi})
+	}
+
+	return cl, nil
+}
+
+// parseColumnNumber returns the zero-based column index for the one-based
+// column number s.
+func parseColumnNumber(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("cannot parse column number as positive integer: %q", s)
+	}
+	return n - 1, nil
+}
+
+// contains returns true when column index i is in the list.
+func (cl columnList) contains(i int) bool {
+	for _, cr := range cl {
+		if i >= cr.lo && (cr.hi < 0 || i <= cr.hi) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexes returns the column indexes in the list, in the order listed, for a
+// table with n columns. Indexes beyond the final column are omitted.
+func (cl columnList) indexes(n int) []int {
+	var indexes []int
+	for _, cr := range cl {
+		hi := cr.hi
+		if hi < 0 || hi >= n {
+			hi = n - 1
+		}
+		for i := cr.lo; i <= hi; i++ {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// columnCount returns the number of fields in the widest row of lines.
+func columnCount(lines [][]string) int {
+	var n int
+	for _, fields := range lines {
+		if len(fields) > n {
+			n = len(fields)
+		}
+	}
+	return n
+}
+
+// projectColumns rebuilds each row of lines from its fields at the specified
+// column indexes, in order. Rows too short to have a field at a particular
+// index receive an empty field in its place, although empty fields at the end
+// of a row are dropped, in keeping with how short rows are otherwise handled.
+func projectColumns(lines [][]string, indexes []int) {
+	for li, fields := range lines {
+		projected := make([]string, 0, len(indexes))
+		for _, i := range indexes {
+			if i < len(fields) {
+				projected = append(projected, fields[i])
+			} else {
+				projected = append(projected, "")
+			}
+		}
+		for len(projected) > 0 && projected[len(projected)-1] == "" {
+			projected = projected[:len(projected)-1]
+		}
+		lines[li] = projected
+	}
+}
+
+// fillEmpty replaces the empty fields of each row of rows with placeholder,
+// first extending rows with fewer than n fields, so that every row has at
+// least n fields.
+func fillEmpty(rows [][]string, n int, placeholder string) {
+	for ri, fields := range rows {
+		if fields == nil {
+			continue // rule
+		}
+		for len(fields) < n {
+			fields = append(fields, "")
+		}
+		for i, field := range fields {
+			if field == "" {
+				fields[i] = placeholder
+			}
+		}
+		rows[ri] = fields
+	}
+}
+
+// headerIndexes returns the indexes of the columns of header whose labels
+// match the specified names, in the order the names are listed. Labels are
+// compared without regard to case, and each name may be a glob pattern, in
+// which case all matching columns are returned in their original order.
+func headerIndexes(header []string, names []string) ([]int, error) {
+	var indexes []int
+	for _, name := range names {
+		pattern := strings.ToLower(name)
+		var matched bool
+		for i, label := range header {
+			ok, err := path.Match(pattern, strings.ToLower(label))
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse column name as glob pattern: %q", name)
+			}
+			if ok {
+				indexes = append(indexes, i)
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("cannot find column with header label: %q", name)
+		}
+	}
+	return indexes, nil
+}
+
+// keptIndexes returns, in order, the indexes of the n columns of a table whose
+// header is the specified row, omitting those in the drop list and those whose
+// header labels match the drop pattern, either of which may be nil.
+func keptIndexes(n int, header []string, drop columnList, pattern *regexp.Regexp) []int {
+	indexes := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if drop.contains(i) {
+			continue
+		}
+		if pattern != nil && i < len(header) && pattern.MatchString(header[i]) {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// widthList holds a width for all columns, and widths for particular columns,
+// as provided on the command line, such as "20,3:40" for a width of 20 for
+// every column except the third, which has a width of 40.
+type widthList struct {
+	all  int
+	cols map[int]int
+}
+
+// parseWidthList returns the widthList described by s.
+func parseWidthList(s string) (widthList, error) {
+	wl := widthList{cols: make(map[int]int)}
+
+	for _, item := range strings.Split(s, ",") {
+		column, value, err := splitColumnItem(item)
+		if err != nil {
+			return wl, err
+		}
+		width, err := strconv.Atoi(value)
+		if err != nil || width < 1 {
+			return wl, fmt.Errorf("cannot parse width as positive integer: %q", value)
+		}
+		if column < 0 {
+			wl.all = width
+		} else {
+			wl.cols[column] = width
+		}
+	}
+
+	return wl, nil
+}
+
+// get returns the width for the column with index i, or 0 when there is none.
+func (wl widthList) get(i int) int {
+	if width, ok := wl.cols[i]; ok {
+		return width
+	}
+	return wl.all
+}
+
+// splitColumnItem returns the zero-based column index and the value of an
+// item of a per column list, such as "3:40", or -1 for the column index when
+// the item has no column prefix and applies to all columns.
+func splitColumnItem(item string) (int, string, error) {
+	item = strings.TrimSpace(item)
+	i := strings.IndexByte(item, ':')
+	if i < 0 {
+		return -1, item, nil
+	}
+	column, err := parseColumnNumber(item[:i])
+	if err != nil {
+		return 0, "", err
+	}
+	return column, item[i+1:], nil
+}
+
+// parseFixedWidths returns the column widths listed in s, such as
+// "12,8,8,30", one for each column in order.
+func parseFixedWidths(s string) ([]int, error) {
+	var widths []int
+	for _, item := range strings.Split(s, ",") {
+		width, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || width < 1 {
+			return nil, fmt.Errorf("cannot parse width as positive integer: %q", item)
+		}
+		widths = append(widths, width)
+	}
+	return widths, nil
+}
+
+// Overflow policies determine what happens to fields wider than their column.
+const (
+	overflowTruncate = "truncate" // shorten field and append an ellipsis
+	overflowWrap     = "wrap"     // word wrap field onto continuation lines
+	overflowOverflow = "overflow" // print entire field, misaligning its row
+)
+
+// policyList holds an overflow policy for all columns, and overflow policies
+// for particular columns, as provided on the command line, such as
+// "truncate,4:wrap".
+type policyList struct {
+	all  string
+	cols map[int]string
+}
+
+// parsePolicyList returns the policyList described by s.
+func parsePolicyList(s string) (policyList, error) {
+	pl := policyList{cols: make(map[int]string)}
+
+	for _, item := range strings.Split(s, ",") {
+		column, value, err := splitColumnItem(item)
+		if err != nil {
+			return pl, err
+		}
+		switch value {
+		case overflowOverflow, overflowTruncate, overflowWrap:
+		default:
+			return pl, fmt.Errorf("cannot parse overflow policy: %q", value)
+		}
+		if column < 0 {
+			pl.all = value
+		} else {
+			pl.cols[column] = value
+		}
+	}
+
+	return pl, nil
+}
+
+// get returns the overflow policy for the column with index i, defaulting to
+// truncation.
+func (pl policyList) get(i int) string {
+	if policy, ok := pl.cols[i]; ok {
+		return policy
+	}
+	if pl.all != "" {
+		return pl.all
+	}
+	return overflowTruncate
+}
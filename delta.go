@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+// deltaFiles aligns the rows of the before file which have a matching row, by
+// first column, in the after file. For each column numeric in both files, it
+// emits the before and after values, followed by their absolute and
+// percentage change. Other columns are emitted as found in the after file.
+func deltaFiles(ctx context.Context, before, after string, iow io.Writer) error {
+	var tables [2]*table
+	headerLines := optHeaderLines // each file has its own header lines
+	for i, path := range []string{before, after} {
+		t, err := newTable()
+		if err != nil {
+			return err
+		}
+		w := iow
+		if i > 0 {
+			w = ioutil.Discard // the header lines of the after file repeat those of the before file
+		}
+		optHeaderLines = headerLines
+		err = withOpenFile(ctx, path, func(r io.Reader) error {
+			return t.read(r, w, "")
+		})
+		if err != nil {
+			return err
+		}
+		tables[i] = t
+	}
+
+	// Columns are classified once, over the rows of both files, so each
+	// column of the output holds the same kind of field on every row.
+	numeric, afterNumeric := numericColumns(tables[0].lines), numericColumns(tables[1].lines)
+	for i := range numeric {
+		numeric[i] = numeric[i] && afterNumeric[i]
+	}
+	numeric[0] = false // the column matching rows
+	n := columnCount(tables[0].lines)
+	if m := columnCount(tables[1].lines); m > n {
+		n = m
+	}
+
+	afterRows := make(map[string][]string, len(tables[1].lines))
+	for _, fields := range tables[1].lines {
+		if len(fields) > 0 {
+			if _, ok := afterRows[fields[0]]; !ok {
+				afterRows[fields[0]] = fields
+			}
+		}
+	}
+
+	t := &table{cb: tables[1].cb, signed: make(map[int]bool), percent: make(map[int]bool)}
+	for _, fields := range tables[0].block {
+		t.block = append(t.block, deltaHeader(fields, numeric))
+	}
+
+	for _, a := range tables[0].lines {
+		if len(a) == 0 {
+			continue
+		}
+		b, ok := afterRows[a[0]]
+		if !ok {
+			continue
+		}
+		row := []string{a[0]}
+		for j := 1; j < n; j++ {
+			var af, bf string
+			if j < len(a) {
+				af = a[j]
+			}
+			if j < len(b) {
+				bf = b[j]
+			}
+			if !numeric[j] {
+				if bf == "" {
+					bf = af
+				}
+				row = append(row, bf)
+				continue
+			}
+			var change, percent string
+			av, aok := parseNumber(af)
+			bv, bok := parseNumber(bf)
+			if aok && bok {
+				change, percent = formatDelta(bv-av), formatPercent(av, bv)
+			}
+			row = append(row, af, bf, change, percent)
+			t.signed[len(row)-2] = true
+			t.signed[len(row)-1] = true
+			t.percent[len(row)-1] = true
+		}
+		t.lines = append(t.lines, row)
+	}
+
+	return t.write(iow)
+}
+
+// deltaHeader returns the header row labeling the columns of the rows emitted
+// by deltaFiles, where each numeric column is followed by the columns of its
+// after value, and its absolute and percentage change.
+func deltaHeader(fields []string, numeric map[int]bool) []string {
+	var header []string
+	for j, field := range fields {
+		header = append(header, field)
+		if numeric[j] {
+			header = append(header, field, "delta", "%")
+		}
+	}
+	return header
+}
+
+// formatDelta returns the text of the difference d, always showing its sign.
+func formatDelta(d float64) string {
+	s := formatValue(value{f: d, numeric: true})
+	if d > 0 {
+		s = "+" + s
+	}
+	return s
+}
+
+// formatPercent returns the percentage change from before to after, always
+// showing its sign, or the empty string when before is zero.
+func formatPercent(before, after float64) string {
+	if before == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%+.2f%%", (after-before)/before*100)
+}
+
+// errDeltaFiles is returned when delta mode is not given exactly two files.
+var errDeltaFiles = errors.New("cannot use --delta without exactly two files")
+
+// percentValue returns the value of field when it is a number followed by a
+// percent sign, such as "+12.34%".
+func percentValue(field string) (float64, bool) {
+	if l := len(field); l > 1 && field[l-1] == '%' {
+		if f, err := strconv.ParseFloat(field[:l-1], 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
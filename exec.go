@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// execCommand runs command with the shell, invoking callback with its
+// standard output while it runs, and passing its standard error through.
+// When callback succeeds, the error is that of the command, which is an
+// *exec.ExitError when the command exits with a non-zero status. Canceling
+// ctx, or the user interrupting the program, kills the command.
+func execCommand(ctx context.Context, command string, callback func(io.Reader) error) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+
+	err = callback(out)
+	if err != nil || isInterrupted() {
+		// Stop a command whose output is no longer read.
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+	return cmd.Wait()
+}
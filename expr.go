@@ -0,0 +1,380 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// value is the result of evaluating an expression against a row. A value is
+// numeric when f holds a number; otherwise s holds its text.
+type value struct {
+	f       float64
+	s       string
+	numeric bool
+}
+
+// truthy returns true when v is a non-zero number or non-empty text.
+func (v value) truthy() bool {
+	if v.numeric {
+		return v.f != 0
+	}
+	return v.s != ""
+}
+
+// boolean returns the numeric value for b: 1 when true, and 0 when false.
+func boolean(b bool) value {
+	if b {
+		return value{f: 1, numeric: true}
+	}
+	return value{f: 0, numeric: true}
+}
+
+// expression is a compiled expression, evaluated against the fields of a row.
+type expression func(fields []string) value
+
+// computed is a named expression whose value is appended to each row as a new
+// column.
+type computed struct {
+	name string
+	expr expression
+}
+
+// parseComputed returns the computed column described by s, such as
+// "ratio=$3/$2".
+func parseComputed(s string) (computed, error) {
+	i := strings.IndexByte(s, '=')
+	if i < 1 {
+		return computed{}, fmt.Errorf("cannot parse computed column without name: %q", s)
+	}
+	expr, err := compileExpression(s[i+1:])
+	if err != nil {
+		return computed{}, err
+	}
+	return computed{name: strings.TrimSpace(s[:i]), expr: expr}, nil
+}
+
+// compileExpression returns the expression described by s, which may contain
+// numbers, double or single quoted strings, references to fields by column
+// number, such as $3, the arithmetic operators +, -, *, /, and %, the
+// comparison operators ==, !=, <, <=, >, and >=, the logical operators &&,
+// ||, and !, and parentheses. Comparisons are numeric when both operands are
+// numbers, and lexical otherwise. Comparisons and logical operators evaluate
+// to 1 when true and 0 when false.
+func compileExpression(s string) (expression, error) {
+	p := &exprParser{input: s}
+	p.next()
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" {
+		return nil, fmt.Errorf("cannot parse expression: unexpected %q in %q", p.token, s)
+	}
+	return expr, nil
+}
+
+// exprParser is a recursive descent parser for expressions.
+type exprParser struct {
+	input string // remaining input after the current token
+	token string // current token, or empty string at end of input
+}
+
+// next advances the parser to the next token of input.
+func (p *exprParser) next() {
+	p.input = strings.TrimLeft(p.input, " \t")
+	if p.input == "" {
+		p.token = ""
+		return
+	}
+
+	var n int
+	switch c := p.input[0]; {
+	case c == '$' || c == '.' || (c >= '0' && c <= '9'):
+		n = 1
+		for n < len(p.input) && (p.input[n] == '.' || (p.input[n] >= '0' && p.input[n] <= '9')) {
+			n++
+		}
+	case c == '"' || c == '\'':
+		// Quoted strings extend through the matching quote, or the end of
+		// input, in which case parseOperand reports the error.
+		n = 1
+		for n < len(p.input) && p.input[n] != c {
+			if p.input[n] == '\\' && n+1 < len(p.input) {
+				n++
+			}
+			n++
+		}
+		if n < len(p.input) {
+			n++
+		}
+	default:
+		n = 1
+		if len(p.input) > 1 {
+			switch p.input[:2] {
+			case "&&", "||", "==", "!=", "<=", ">=":
+				n = 2
+			}
+		}
+	}
+
+	p.token, p.input = p.input[:n], p.input[n:]
+}
+
+// parseOr parses operands joined by logical or.
+func (p *exprParser) parseOr() (expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.token == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(fields []string) value {
+			return boolean(l(fields).truthy() || right(fields).truthy())
+		}
+	}
+	return left, nil
+}
+
+// parseAnd parses operands joined by logical and.
+func (p *exprParser) parseAnd() (expression, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.token == "&&" {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(fields []string) value {
+			return boolean(l(fields).truthy() && right(fields).truthy())
+		}
+	}
+	return left, nil
+}
+
+// parseNot parses an optionally logically negated comparison.
+func (p *exprParser) parseNot() (expression, error) {
+	if p.token == "!" {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(fields []string) value {
+			return boolean(!operand(fields).truthy())
+		}, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses a sum optionally compared with another sum.
+func (p *exprParser) parseComparison() (expression, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	switch op := p.token; op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		right, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return comparison(op, left, right), nil
+	}
+	return left, nil
+}
+
+// parseSum parses terms joined by addition and subtraction.
+func (p *exprParser) parseSum() (expression, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.token == "+" || p.token == "-" {
+		op := p.token
+		p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = arithmetic(op, left, right)
+	}
+	return left, nil
+}
+
+// parseProduct parses factors joined by multiplication, division, and
+// remainder.
+func (p *exprParser) parseProduct() (expression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.token == "*" || p.token == "/" || p.token == "%" {
+		op := p.token
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = arithmetic(op, left, right)
+	}
+	return left, nil
+}
+
+// parseUnary parses an optionally negated operand.
+func (p *exprParser) parseUnary() (expression, error) {
+	if p.token == "-" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(fields []string) value {
+			// The text of a negated field no longer describes its value.
+			v := operand(fields)
+			return value{f: -v.f, numeric: v.numeric}
+		}, nil
+	}
+	return p.parseOperand()
+}
+
+// parseOperand parses a number, a field reference, or a parenthesized
+// expression.
+func (p *exprParser) parseOperand() (expression, error) {
+	token := p.token
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("cannot parse expression: unexpected end of input")
+	case token == "(":
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.token != ")" {
+			return nil, fmt.Errorf("cannot parse expression: missing closing parenthesis")
+		}
+		p.next()
+		return expr, nil
+	case token[0] == '$':
+		column, err := parseColumnNumber(token[1:])
+		if err != nil {
+			return nil, err
+		}
+		p.next()
+		return func(fields []string) value {
+			if column >= len(fields) {
+				return value{}
+			}
+			f, ok := parseNumber(fields[column])
+			return value{f: f, s: fields[column], numeric: ok}
+		}, nil
+	case token[0] == '"' || token[0] == '\'':
+		if len(token) < 2 || token[len(token)-1] != token[0] {
+			return nil, fmt.Errorf("cannot parse expression: missing closing quote")
+		}
+		text := strings.Replace(token[1:len(token)-1], "\\"+token[:1], token[:1], -1)
+		p.next()
+		return func([]string) value { return value{s: text} }, nil
+	default:
+		f, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse expression: unexpected %q", token)
+		}
+		p.next()
+		return func([]string) value { return value{f: f, numeric: true} }, nil
+	}
+}
+
+// arithmetic returns an expression that applies the arithmetic operator op to
+// the values of left and right. The result is not numeric when either operand
+// is not numeric, or when dividing by zero.
+func arithmetic(op string, left, right expression) expression {
+	return func(fields []string) value {
+		l, r := left(fields), right(fields)
+		if !l.numeric || !r.numeric {
+			return value{}
+		}
+		switch op {
+		case "+":
+			return value{f: l.f + r.f, numeric: true}
+		case "-":
+			return value{f: l.f - r.f, numeric: true}
+		case "*":
+			return value{f: l.f * r.f, numeric: true}
+		case "/":
+			if r.f == 0 {
+				return value{}
+			}
+			return value{f: l.f / r.f, numeric: true}
+		default:
+			if r.f == 0 {
+				return value{}
+			}
+			return value{f: math.Mod(l.f, r.f), numeric: true}
+		}
+	}
+}
+
+// comparison returns an expression that compares the values of left and right
+// using the comparison operator op, numerically when both are numbers, and
+// lexically otherwise. A number compared lexically is written as it appears in
+// its field, so that "007" and "1,234" keep their form, while numbers that are
+// not fields, such as the results of arithmetic, are formatted.
+func comparison(op string, left, right expression) expression {
+	return func(fields []string) value {
+		l, r := left(fields), right(fields)
+		var c int
+		if l.numeric && r.numeric {
+			switch {
+			case l.f < r.f:
+				c = -1
+			case l.f > r.f:
+				c = 1
+			}
+		} else {
+			ls, rs := l.s, r.s
+			if l.numeric && ls == "" {
+				ls = formatValue(l)
+			}
+			if r.numeric && rs == "" {
+				rs = formatValue(r)
+			}
+			c = strings.Compare(ls, rs)
+		}
+		switch op {
+		case "==":
+			return boolean(c == 0)
+		case "!=":
+			return boolean(c != 0)
+		case "<":
+			return boolean(c < 0)
+		case "<=":
+			return boolean(c <= 0)
+		case ">":
+			return boolean(c > 0)
+		default:
+			return boolean(c >= 0)
+		}
+	}
+}
+
+// formatValue returns the text of v, rounded to at most six decimal places,
+// or the empty string when v is not numeric.
+func formatValue(v value) string {
+	if !v.numeric {
+		return ""
+	}
+	return strconv.FormatFloat(math.Round(v.f*1e6)/1e6, 'f', -1, 64)
+}
@@ -0,0 +1,114 @@
+package main
+
+import "testing"
+
+func TestCompileExpression(t *testing.T) {
+	// Results are given as text: the formatted number of numeric results,
+	// and the text of the others.
+	tests := []struct {
+		name    string
+		expr    string
+		fields  []string
+		want    string
+		numeric bool
+	}{
+		// precedence and associativity
+		{name: "product before sum", expr: "1 + 2 * 3", want: "7", numeric: true},
+		{name: "parentheses", expr: "(1 + 2) * 3", want: "9", numeric: true},
+		{name: "subtraction is left associative", expr: "10 - 4 - 3", want: "3", numeric: true},
+		{name: "division is left associative", expr: "24 / 4 / 2", want: "3", numeric: true},
+		{name: "remainder with product", expr: "2 * 3 % 4", want: "2", numeric: true},
+		{name: "arithmetic before comparison", expr: "1 + 1 == 2", want: "1", numeric: true},
+		{name: "comparison before and", expr: "1 < 2 && 3 > 2", want: "1", numeric: true},
+		{name: "and before or", expr: "1 || 1 && 0", want: "1", numeric: true},
+		{name: "not before and", expr: "!0 && 0", want: "0", numeric: true},
+		{name: "not of comparison", expr: "!1 == 2", want: "1", numeric: true},
+
+		// unary minus
+		{name: "negated number", expr: "-3", want: "-3", numeric: true},
+		{name: "negated operands", expr: "-3 * -2", want: "6", numeric: true},
+		{name: "double negation", expr: "--3", want: "3", numeric: true},
+		{name: "minus negated", expr: "2 - -3", want: "5", numeric: true},
+		{name: "negation before product", expr: "-2 * 3 + 10", want: "4", numeric: true},
+		{name: "negated field", expr: "-$2", fields: []string{"a", "5"}, want: "-5", numeric: true},
+		{name: "negated field compared with its text", expr: `-$2 == "5"`, fields: []string{"a", "5"}, want: "0", numeric: true},
+		{name: "negated field compared with text", expr: `-$2 == "5"`, fields: []string{"a", "-5"}, want: "1", numeric: true},
+		{name: "negated text", expr: "-$1", fields: []string{"abc"}, want: ""},
+
+		// string versus numeric comparison
+		{name: "numbers compare numerically", expr: "$1 < $2", fields: []string{"10", "9"}, want: "0", numeric: true},
+		{name: "text compares lexically", expr: "$1 < $2", fields: []string{"b", "a"}, want: "0", numeric: true},
+		{name: "number with text compares lexically", expr: "$1 < $2", fields: []string{"10", "9a"}, want: "1", numeric: true},
+		{name: "field keeps its form", expr: `$1 == "007"`, fields: []string{"007"}, want: "1", numeric: true},
+		{name: "field equals number", expr: "$1 == 7", fields: []string{"007"}, want: "1", numeric: true},
+		{name: "grouped field keeps its form", expr: `$1 == "1,234"`, fields: []string{"1,234"}, want: "1", numeric: true},
+		{name: "computed number is formatted", expr: `$1 * 2 == "2.5"`, fields: []string{"1.25"}, want: "1", numeric: true},
+		{name: "quoted text", expr: `'it\'s' != "it's"`, want: "0", numeric: true},
+		{name: "arithmetic on text", expr: "$1 + 1", fields: []string{"abc"}, want: ""},
+		{name: "division by zero", expr: "1 / 0", want: ""},
+		{name: "remainder by zero", expr: "1 % 0", want: ""},
+
+		// missing fields
+		{name: "missing field", expr: "$3", fields: []string{"a"}, want: ""},
+		{name: "missing field is empty", expr: `$3 == ""`, fields: []string{"a"}, want: "1", numeric: true},
+		{name: "missing field is not a number", expr: "$3 + 1", fields: []string{"a"}, want: ""},
+		{name: "missing field is false", expr: "$3 || 0", fields: []string{"a"}, want: "0", numeric: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := compileExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("compileExpression(%q): %s", tt.expr, err)
+			}
+			v := expr(tt.fields)
+			got := v.s
+			if v.numeric {
+				got = formatValue(v)
+			}
+			if got != tt.want || v.numeric != tt.numeric {
+				t.Errorf("%q of %q = %q (numeric: %t); want %q (numeric: %t)", tt.expr, tt.fields, got, v.numeric, tt.want, tt.numeric)
+			}
+		})
+	}
+}
+
+func TestCompileExpressionErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"1 +",
+		"* 2",
+		"(1 + 2",
+		"1 2",
+		"1 )",
+		"$",
+		"$0",
+		"$x",
+		`"abc`,
+		"1 @ 2",
+		"1 == == 2",
+	} {
+		if _, err := compileExpression(expr); err == nil {
+			t.Errorf("compileExpression(%q) returned no error", expr)
+		}
+	}
+}
+
+func TestParseComputed(t *testing.T) {
+	c, err := parseComputed(" ratio = $2 / $1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.name != "ratio" {
+		t.Errorf("name = %q; want %q", c.name, "ratio")
+	}
+	if got := formatValue(c.expr([]string{"4", "3"})); got != "0.75" {
+		t.Errorf("value = %q; want %q", got, "0.75")
+	}
+
+	for _, s := range []string{"$1 + 2", "=$1", "ratio=$1 +"} {
+		if _, err := parseComputed(s); err == nil {
+			t.Errorf("parseComputed(%q) returned no error", s)
+		}
+	}
+}
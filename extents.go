@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// extentSample is the number of input lines shown beneath the extent overlay.
+const extentSample = 10
+
+// extent is a half-open range of character positions, [start, end), that
+// holds a column of positionally aligned input.
+type extent struct {
+	start, end int
+}
+
+// tabWidth is the distance between the tab stops of input lines, as terminals
+// display them.
+const tabWidth = 8
+
+// expandTabs returns text with each tab replaced by the spaces that advance to
+// the following tab stop, so that the position of each character is the
+// position at which it is displayed.
+func expandTabs(text string) string {
+	if strings.IndexByte(text, '\t') < 0 {
+		return text
+	}
+	var sb strings.Builder
+	var position int
+	for _, r := range text {
+		if r == '\t' {
+			n := tabWidth - position%tabWidth
+			sb.WriteString(strings.Repeat(" ", n))
+			position += n
+			continue
+		}
+		sb.WriteRune(r)
+		position++
+	}
+	return sb.String()
+}
+
+// detectExtents returns the column extents of texts, once their tabs are
+// expanded. A character position is occupied when any of the lines has a
+// non-space character there, and each maximal run of occupied positions
+// merges into a single extent. Therefore cells of a column that are left
+// justified on some lines and right justified on others still fall within
+// the same extent, provided a column of spaces separates them from their
+// neighbors.
+func detectExtents(texts []string) []extent {
+	var occupied []bool
+	for _, text := range texts {
+		var i int
+		for _, r := range expandTabs(text) {
+			if i == len(occupied) {
+				occupied = append(occupied, false)
+			}
+			if !unicode.IsSpace(r) {
+				occupied[i] = true
+			}
+			i++
+		}
+	}
+
+	var extents []extent
+	start := -1
+	for i, ok := range occupied {
+		if ok && start < 0 {
+			start = i
+		} else if !ok && start >= 0 {
+			extents = append(extents, extent{start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		extents = append(extents, extent{start: start, end: len(occupied)})
+	}
+	return extents
+}
+
+// splitExtents returns the cells of text that fall within each of extents,
+// once its tabs are expanded, with surrounding whitespace trimmed. Cells of
+// text that has nothing within an extent are empty.
+func splitExtents(text string, extents []extent) []string {
+	runes := []rune(expandTabs(text))
+	cells := make([]string, len(extents))
+	for i, e := range extents {
+		if e.start >= len(runes) {
+			continue
+		}
+		end := e.end
+		if end > len(runes) {
+			end = len(runes)
+		}
+		cells[i] = strings.TrimSpace(string(runes[e.start:end]))
+	}
+	return cells
+}
+
+// splitPositional splits the aligned header lines from blocks, and the rows
+// from rows, by the column extents detected among them, replacing the fields
+// they were split into around whitespace. When the user limits the number of
+// columns to N, the extents from the Nth on merge into the final column.
+func (t *table) splitPositional(blocks, rows int) {
+	texts := append(append([]string(nil), t.blockTexts[blocks:]...), t.texts[rows:]...)
+	extents := detectExtents(texts)
+	if n := int(optMaxColumns); n > 0 && len(extents) > n {
+		extents[n-1].end = extents[len(extents)-1].end
+		extents = extents[:n]
+	}
+
+	var prefix int // number of leading fields not from the text, i.e., file name
+	if optWithFilename {
+		prefix = 1
+	}
+	resplit := func(fields []string, text string) []string {
+		cells := splitExtents(text, extents)
+		if optDecimal {
+			for i, cell := range cells {
+				cells[i] = normalizeBase(cell)
+			}
+		}
+		return append(fields[:prefix:prefix], cells...)
+	}
+	for i := blocks; i < len(t.block); i++ {
+		t.block[i] = resplit(t.block[i], t.blockTexts[i])
+	}
+	for i := rows; i < len(t.lines); i++ {
+		t.lines[i] = resplit(t.lines[i], t.texts[i])
+	}
+}
+
+// overlay returns a line that marks each extent with its 1-based column
+// number, such as "[---1---] [--2--]", positioned above the characters the
+// extent covers.
+func overlay(extents []extent) string {
+	var sb strings.Builder
+	var position int
+	for i, e := range extents {
+		sb.WriteString(strings.Repeat(" ", e.start-position))
+		sb.WriteString(extentMark(i+1, e.end-e.start))
+		position = e.end
+	}
+	return sb.String()
+}
+
+// extentMark returns a width character mark labeled with column n. Extents
+// too narrow for brackets are marked with as much of the label as fits.
+func extentMark(n, width int) string {
+	label := strconv.Itoa(n)
+	if width < len(label)+2 {
+		if width < len(label) {
+			return strings.Repeat("-", width)
+		}
+		return label + strings.Repeat("-", width-len(label))
+	}
+	dashes := width - len(label) - 2
+	return "[" + strings.Repeat("-", dashes/2) + label + strings.Repeat("-", dashes-dashes/2) + "]"
+}
+
+// showExtents writes to iow the character range of each column extent
+// detected in the rows of the table, followed by an overlay marking the
+// extents above a sample of the input lines.
+func (t *table) showExtents(iow io.Writer) error {
+	extents := detectExtents(t.texts)
+	for i, e := range extents {
+		fmt.Fprintf(iow, "extent %d: characters %d-%d\n", i+1, e.start+1, e.end)
+	}
+
+	fmt.Fprintf(iow, "%s\n", overlay(extents))
+	sample := t.texts
+	if len(sample) > extentSample {
+		sample = sample[:extentSample]
+	}
+	for _, text := range sample {
+		fmt.Fprintf(iow, "%s\n", expandTabs(text)) // so the overlay lines up
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import "strings"
+
+// uniqueRows returns rows without the rows that duplicate an earlier row,
+// preserving the order of first occurrence. Rows are compared by their field
+// at the specified column index, or by all of their fields when column is
+// negative. Rows without a value in that column are compared by all of their
+// fields, so they are only omitted when they duplicate an earlier row.
+func uniqueRows(rows [][]string, column int) [][]string {
+	seenFields := make(map[string]bool) // values of the column of rows compared by it
+	seenRows := make(map[string]bool)   // rows compared by all of their fields
+	kept := rows[:0]
+	for _, fields := range rows {
+		var key string
+		seen := seenFields
+		if column >= 0 {
+			key = fieldAt(fields, column)
+		}
+		if key == "" {
+			// Join with a byte which cannot appear within a field, so
+			// different splits of the same text remain distinct.
+			key, seen = strings.Join(fields, "\x00"), seenRows
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, fields)
+	}
+	return kept
+}
+
+// separateGroups returns lines with a separator row inserted between each pair
+// of adjacent rows, from index lo up to index hi, whose fields at the
+// specified column index differ. Separator rows are nil, which render as
+// rules, and when blank is true, the separator rows are also added to
+// verbatim, so they render as blank lines instead.
+func separateGroups(lines [][]string, lo, hi, column int, blank bool, verbatim map[int]string) [][]string {
+	separated := make([][]string, 0, len(lines))
+	separated = append(separated, lines[:lo]...)
+	for li := lo; li < hi; li++ {
+		if li > lo && fieldAt(lines[li], column) != fieldAt(lines[li-1], column) {
+			if blank {
+				verbatim[len(separated)] = ""
+			}
+			separated = append(separated, nil)
+		}
+		separated = append(separated, lines[li])
+	}
+	return append(separated, lines[hi:]...)
+}
+
+// insertPassthrough returns lines with the passthrough lines inserted among
+// the rows from index lo up to index hi, along with verbatim, which holds the
+// text of the rows rendered without alignment, rekeyed by the indexes of the
+// returned lines. Each passthrough line precedes the row it preceded in the
+// input, counting rows in order, but not counting separator rows, and those
+// which followed more rows than remain are inserted after the final row.
+func insertPassthrough(lines [][]string, lo, hi int, verbatim map[int]string, passthrough []passthroughLine) ([][]string, map[int]string) {
+	inserted := make([][]string, 0, len(lines)+len(passthrough))
+	rekeyed := make(map[int]string, len(verbatim)+len(passthrough))
+
+	// emit inserts the passthrough lines which preceded the specified number
+	// of rows.
+	emit := func(rows int) {
+		for len(passthrough) > 0 && passthrough[0].rows <= rows {
+			rekeyed[len(inserted)] = passthrough[0].text
+			inserted = append(inserted, nil)
+			passthrough = passthrough[1:]
+		}
+	}
+
+	var rows int
+	for li, fields := range lines {
+		if li == hi {
+			emit(len(lines))
+		}
+		if text, ok := verbatim[li]; ok {
+			rekeyed[len(inserted)] = text
+		} else if li >= lo && li < hi && fields != nil {
+			emit(rows)
+			rows++
+		}
+		inserted = append(inserted, fields)
+	}
+	emit(len(lines))
+
+	return inserted, rekeyed
+}
+
+// fieldAt returns the field of fields at column index i, or the empty string
+// when fields is too short to have one.
+func fieldAt(fields []string, i int) string {
+	if i < len(fields) {
+		return fields[i]
+	}
+	return ""
+}
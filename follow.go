@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/karrick/columnize/pkg/ringbuf"
+)
+
+// followPoll is the time between attempts to read more of a followed file
+// after reaching its end.
+const followPoll = 250 * time.Millisecond
+
+// followReader reads from r, but rather than returning io.EOF at the end of
+// r, waits for more data to be appended to it, like 'tail -f', until ctx is
+// canceled.
+type followReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (fr *followReader) Read(buf []byte) (int, error) {
+	for {
+		n, err := fr.r.Read(buf)
+		if n > 0 || (err != nil && err != io.EOF) {
+			return n, err
+		}
+		select {
+		case <-fr.ctx.Done():
+			return 0, fr.ctx.Err()
+		case <-time.After(followPoll):
+		}
+	}
+}
+
+// stream aligns the lines read from ior, writing the rows to iow as they are
+// read, rather than after reading all of them: each row as soon as it is
+// read, or when interval is not zero, the rows read during each interval as
+// it elapses. The options which transform each row on its own are applied
+// to the rows as they are read. Each column is as wide as the widest of its
+// fields read so far, so rows that widen a column are misaligned with the
+// rows before them. When the user reprints the header, the aligned header
+// rows are written again after such rows, so the rows that follow align with
+// them.
+func stream(ior io.Reader, iow io.Writer, interval time.Duration) error {
+	cb := ringbuf.New[string](int(optFooterLines))
+	var rt rowTransform
+	addHeader := optAddHeader != nil // rendered before the first row
+	var widths []int
+	var header, batch [][]string
+	verbatim := make(map[int]string) // passthrough lines of the batch
+
+	// widen grows the widths to fit fields, and returns true when any of them
+	// grew.
+	widen := func(fields []string) bool {
+		var widened bool
+		widths = growWidths(widths, len(fields))
+		for i, field := range fields {
+			if w := displayWidth(field); w > widths[i] {
+				widths[i], widened = w, true
+			}
+		}
+		return widened
+	}
+
+	flush := func() {
+		defer output.Flush() // write the rows now, rather than when output fills
+		if len(batch) == 0 {
+			return
+		}
+		var widened bool
+		for i, fields := range batch {
+			if _, ok := verbatim[i]; !ok && widen(fields) {
+				widened = true
+			}
+		}
+		render(iow, layout{lines: batch, verbatim: verbatim, minimums: widths})
+		if widened && optReprintHeader && header != nil {
+			render(iow, layout{lines: header, heads: len(header), minimums: widths})
+		}
+		for _, fields := range batch {
+			putFields(fields)
+		}
+		batch, verbatim = batch[:0], make(map[int]string)
+	}
+
+	// writeHeader aligns and writes a header row, which later rows align
+	// with.
+	writeHeader := func(fields []string) error {
+		fields, _, err := rt.apply(fields, true)
+		if err != nil {
+			return err
+		}
+		header = append(header, fields)
+		widen(fields)
+		render(iow, layout{lines: [][]string{fields}, heads: 1, minimums: widths})
+		return nil
+	}
+
+	// Lines are read concurrently, so the rows read so far may be flushed
+	// while waiting for more.
+	lines := make(chan string)
+	var scanErr error
+	go func() {
+		br := newScanner(ior)
+		for br.Scan() {
+			lines <- br.Text()
+		}
+		scanErr = br.Err()
+		close(lines)
+	}()
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		var text string
+		var ok bool
+		select {
+		case <-tick:
+			flush()
+			continue
+		case <-interrupted:
+		case text, ok = <-lines:
+		}
+		if !ok {
+			break // at the end of the input, or interrupted by the user
+		}
+		countLine(text)
+
+		if optHeaderLines > 0 {
+			optHeaderLines--
+			if !optFormatHeader {
+				fmt.Fprintf(iow, "%s\n", text)
+			} else if err := writeHeader(splitFields(text)); err != nil {
+				return err
+			}
+			output.Flush()
+			continue
+		}
+		if addHeader {
+			addHeader = false
+			// Copy the synthetic header, because fields may be rewritten.
+			if err := writeHeader(append([]string(nil), optAddHeader...)); err != nil {
+				return err
+			}
+		}
+
+		text, ok = cb.QueueDequeue(text)
+		if !ok {
+			continue
+		}
+
+		if unaligned(text) {
+			verbatim[len(batch)] = text
+			batch = append(batch, []string{})
+		} else {
+			fields, ok, err := rt.apply(appendFields(getFields(), text), false)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue // filtered out by --where
+			}
+			batch = append(batch, fields)
+		}
+		if interval == 0 {
+			flush()
+		}
+	}
+	flush()
+	if !isInterrupted() && scanErr != nil {
+		return scanErr
+	}
+
+	for _, text := range cb.Drain() {
+		fmt.Fprintf(iow, "%s\n", text)
+	}
+	return nil
+}
+
+// rowTransform applies the options which transform each row on its own to
+// the rows streamed by stream, in the order write applies them to a table.
+// Options which name columns by their labels take them from the first row
+// streamed, as write takes them from the first row of a table, as it is when
+// each option is applied.
+type rowTransform struct {
+	rows     int      // number of rows transformed
+	selected []int    // indexes of the columns labeled by --select
+	dropping []string // labels of the columns for --drop-matching
+	masked   []int    // indexes of the columns labeled by --mask-by-header
+	header   []string // last header row, which labels pseudonyms
+	columns  int      // number of columns of the first row, for --compute
+}
+
+// rowIndexes returns the indexes of the columns of cl for a row of n fields.
+// Unlike indexes, the columns of closed ranges beyond the end of the row are
+// kept, because rows that follow may be wider.
+func rowIndexes(cl columnList, n int) []int {
+	for _, cr := range cl {
+		if cr.hi >= n {
+			n = cr.hi + 1
+		}
+	}
+	return cl.indexes(n)
+}
+
+// apply returns fields transformed, along with false when the row is filtered
+// out. Header rows are projected, but not otherwise transformed, like the
+// header rows of a table.
+func (rt *rowTransform) apply(fields []string, head bool) ([]string, bool, error) {
+	first := rt.rows == 0
+	rt.rows++
+
+	if optDecimal && !head {
+		for i, field := range fields {
+			fields[i] = normalizeBase(field)
+		}
+	}
+	if optAttachUnits && !head {
+		fields = attachUnits(fields)
+	}
+
+	row := [][]string{fields}
+	if optFields != nil {
+		projectColumns(row, rowIndexes(optFields, len(row[0])))
+	}
+	if optSelect != nil {
+		if first {
+			indexes, err := headerIndexes(row[0], optSelect)
+			if err != nil {
+				return nil, false, err
+			}
+			rt.selected = indexes
+		}
+		projectColumns(row, rt.selected)
+	}
+	if optDrop != nil || optDropMatching != nil {
+		if first {
+			rt.dropping = append([]string(nil), row[0]...)
+		}
+		n := len(row[0])
+		if len(rt.dropping) > n {
+			n = len(rt.dropping)
+		}
+		projectColumns(row, keptIndexes(n, rt.dropping, optDrop, optDropMatching))
+	}
+	if first && optMaskByHeader != nil {
+		indexes, err := headerIndexes(row[0], optMaskByHeader)
+		if err != nil {
+			return nil, false, err
+		}
+		rt.masked = indexes
+	}
+	if first {
+		rt.columns = len(row[0])
+	}
+	if head {
+		rt.header = row[0]
+	}
+
+	if !head {
+		if optMask != nil || optMaskByHeader != nil {
+			maskColumns(row, append(rowIndexes(optMask, len(row[0])), rt.masked...))
+		}
+		if optAnonymize != nil {
+			anonymizeColumns(row, rowIndexes(optAnonymize, len(row[0])), rt.header)
+		}
+		if optNARep != "" {
+			for i, field := range row[0] {
+				if optNAValues[field] {
+					row[0][i] = optNARep
+				}
+			}
+		}
+	}
+
+	if optCompute != nil {
+		// Pad short rows so computed columns line up.
+		for len(row[0]) < rt.columns {
+			row[0] = append(row[0], "")
+		}
+		for _, c := range optCompute {
+			if head {
+				row[0] = append(row[0], c.name)
+			} else {
+				row[0] = append(row[0], formatValue(c.expr(row[0])))
+			}
+		}
+	}
+
+	if !head {
+		if optWhere != nil && !optWhere(row[0]).truthy() {
+			return nil, false, nil
+		}
+		if optPrecision >= 0 {
+			roundNumbers(row, numericColumns(row))
+		}
+	}
+	if optNegativeStyle != "" {
+		for i, field := range row[0] {
+			row[0][i] = restyleNegative(field, optNegativeStyle)
+		}
+	}
+	if optEmpty != "" && !head {
+		fillEmpty(row, len(row[0]), optEmpty)
+	}
+	return row[0], true, nil
+}
+
+// streamConflicts returns the options the user provides which transform the
+// table as a whole, or otherwise need all of its rows, and so cannot be
+// applied to rows as they are streamed.
+func streamConflicts() []string {
+	var names []string
+	for _, o := range []struct {
+		name string
+		used bool
+	}{
+		{"--ragged or --strict", optRagged != raggedPad},
+		{"--preset", optPreset != ""},
+		{"--unique", optUnique},
+		{"--unique-by", optUniqueBy > 0},
+		{"--group-by", optGroupBy > 0},
+		{"--group-separator", optGroupSeparator > 0},
+		{"--sort", optSort != nil},
+		{"--take", optTake > 0},
+		{"--take-last", optTakeLast > 0},
+		{"--summary", optSummary != nil},
+		{"--align-exponents", optAlignExponents},
+		{"--humanize", optHumanize},
+		{"--group-digits", optGroupDigits},
+		{"--format-footer", optFormatFooter != ""},
+		{"--keep-indent", optKeepIndent},
+		{"--with-filename", optWithFilename},
+		{"--paginate-columns", optPaginateColumns},
+		{"--report-widths", optReportWidths},
+		{"--diagnose", optDiagnose},
+		{"--show-extents", optShowExtents},
+		{"--between", optBetweenStart != nil},
+		{"--section-regex", optSectionRegex != nil},
+		{"--parser positional", optParser == parserPositional},
+	} {
+		if o.used {
+			names = append(names, o.name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// splitUnit returns the number of field and the unit of measure attached to
+// it by attachUnits, including the space that separates them.
+func splitUnit(field string) (string, string) {
+	if optAttachUnits {
+		number := detachUnit(field)
+		return number, field[len(number):]
+	}
+	return field, ""
+}
+
+// roundNumbers rewrites each decimal number in the numeric columns of rows,
+// other than the user's text columns, rounded to the precision the user
+// requested: either a number of decimal places, or with optSigFigs, a number
+// of significant digits. Rewritten numbers have no exponent, so right
+// justified numbers of a column line up by their decimal points.
+func roundNumbers(rows [][]string, numeric map[int]bool) {
+	for _, fields := range rows {
+		for i, field := range fields {
+			if !numeric[i] || optTextColumns.contains(i) {
+				continue
+			}
+			number, unit := splitUnit(field)
+			if !isDecimal(number) {
+				continue
+			}
+			f, err := strconv.ParseFloat(number, 64)
+			if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+				continue
+			}
+			if optSigFigs {
+				fields[i] = significant(f, optPrecision) + unit
+			} else {
+				fields[i] = strconv.FormatFloat(f, 'f', optPrecision, 64) + unit
+			}
+		}
+	}
+}
+
+// isDecimal returns true when field is written as a decimal number, with
+// optional sign, fraction, and exponent, which excludes the special values
+// and base prefixed numbers strconv.ParseFloat also accepts.
+func isDecimal(field string) bool {
+	field = strings.TrimLeft(field, "+-")
+	if field == "" || !strings.ContainsRune("0123456789.", rune(field[0])) {
+		return false
+	}
+	return !strings.ContainsAny(field, "xXpP_")
+}
+
+// significant returns f formatted with digits significant digits, without an
+// exponent. Digits to the left of the decimal point that are not significant
+// are written as zeros.
+func significant(f float64, digits int) string {
+	if f == 0 {
+		return strconv.FormatFloat(0, 'f', digits-1, 64)
+	}
+	magnitude := int(math.Floor(math.Log10(math.Abs(f))))
+	places := digits - 1 - magnitude
+	if places >= 0 {
+		return strconv.FormatFloat(f, 'f', places, 64)
+	}
+	scale := math.Pow(10, float64(-places))
+	return strconv.FormatFloat(math.Round(f/scale)*scale, 'f', 0, 64)
+}
+
+// Humanize bases determine the multiples large numbers are written in.
+const (
+	humanizeSI  = "si"  // powers of 1000: k, M, G, ...
+	humanizeIEC = "iec" // powers of 1024: Ki, Mi, Gi, ...
+)
+
+// humanized matches a number written by humanize.
+var humanized = regexp.MustCompile(`^-?[0-9]+(?:\.[0-9])?(?:k|[KMGTPE]i|[MGTPE])$`)
+
+// humanizeNumbers rewrites each integer in the numeric columns of rows that
+// is at least as large as the base of the user's humanize system, as a
+// multiple of the largest power of the base it exceeds, such as 1.2M or 34G.
+func humanizeNumbers(rows [][]string, numeric map[int]bool) {
+	for _, fields := range rows {
+		for i, field := range fields {
+			if !numeric[i] {
+				continue
+			}
+			number, unit := splitUnit(field)
+			if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+				fields[i] = humanize(n, optHumanizeBase) + unit
+			}
+		}
+	}
+}
+
+// humanize returns n written as a multiple of a power of 1000 for the SI
+// base, or of 1024 for the IEC base, with one fraction digit when the
+// multiple is less than ten. Numbers smaller than the base are returned as
+// is.
+func humanize(n int64, base string) string {
+	divisor, prefixes, suffix := 1000.0, "kMGTPE", ""
+	if base == humanizeIEC {
+		divisor, prefixes, suffix = 1024, "KMGTPE", "i"
+	}
+	f := math.Abs(float64(n))
+	if f < divisor {
+		return strconv.FormatInt(n, 10)
+	}
+	var exponent int
+	for f >= divisor && exponent < len(prefixes) {
+		f /= divisor
+		exponent++
+	}
+	digits := "%.1f"
+	if f >= 9.95 {
+		digits = "%.0f"
+		if f >= divisor-0.5 && exponent < len(prefixes) {
+			f /= divisor // rounds up to the next multiple
+			exponent++
+			digits = "%.1f"
+		}
+	}
+	if n < 0 {
+		f = -f
+	}
+	return fmt.Sprintf(digits, f) + prefixes[exponent-1:exponent] + suffix
+}
+
+// groupNumbers rewrites each integer in the numeric columns of rows with its
+// digits grouped by thousands, separated by the user's digit separator.
+func groupNumbers(rows [][]string, numeric map[int]bool) {
+	for _, fields := range rows {
+		for i, field := range fields {
+			if !numeric[i] {
+				continue
+			}
+			number, unit := splitUnit(field)
+			if _, err := strconv.ParseInt(number, 10, 64); err == nil {
+				fields[i] = groupDigits(number, optDigitSeparator) + unit
+			}
+		}
+	}
+}
+
+// groupDigits returns the integer number with its digits grouped by
+// thousands, each group separated by separator.
+func groupDigits(number, separator string) string {
+	var sign string
+	if number != "" && (number[0] == '-' || number[0] == '+') {
+		sign, number = number[:1], number[1:]
+	}
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i, digit := range number {
+		if i > 0 && (len(number)-i)%3 == 0 {
+			sb.WriteString(separator)
+		}
+		sb.WriteRune(digit)
+	}
+	return sb.String()
+}
+
+// isGrouped returns true when field is an integer written by groupDigits.
+func isGrouped(field string) bool {
+	if !strings.Contains(field, optDigitSeparator) {
+		return false
+	}
+	_, err := strconv.ParseInt(strings.Replace(field, optDigitSeparator, "", -1), 10, 64)
+	return err == nil
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+// expandWildcards returns files as is, because the shell has already expanded
+// the wildcards among the arguments.
+func expandWildcards(files []string) []string {
+	return files
+}
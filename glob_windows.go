@@ -0,0 +1,24 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// expandWildcards returns files with each of them containing wildcards
+// replaced by the names of the files matching it, in lexical order, because
+// the Windows shell leaves expanding wildcards to each program. Patterns
+// matching no files are kept as is, as Unix shells do.
+func expandWildcards(files []string) []string {
+	var expanded []string
+	for _, file := range files {
+		if strings.ContainsAny(file, "*?[") && !isURL(file) {
+			if matches, err := filepath.Glob(file); err == nil && len(matches) > 0 {
+				expanded = append(expanded, matches...)
+				continue
+			}
+		}
+		expanded = append(expanded, file)
+	}
+	return expanded
+}
@@ -5,4 +5,4 @@ require (
 	github.com/karrick/gologs v0.4.0
 )
 
-go 1.13
+go 1.18
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// presetGobench is the name of the preset for the output of `go test -bench`.
+const presetGobench = "gobench"
+
+// gobenchColumns rebuilds rows of `go test -bench` results, each being a
+// benchmark name and iteration count followed by pairs of values and units,
+// such as "1045 ns/op", so that every unit has its own pair of columns, in
+// order of first appearance. Rows missing a unit receive empty fields for it,
+// so the remaining units of the row stay aligned. The first skip fields of
+// each row, such as file names, are kept as is.
+func gobenchColumns(rows [][]string, skip int) {
+	var units []string
+	seen := make(map[string]bool)
+
+	for _, fields := range rows {
+		for j := skip + 3; j < len(fields); j += 2 {
+			if unit := fields[j]; !seen[unit] {
+				seen[unit] = true
+				units = append(units, unit)
+			}
+		}
+	}
+
+	for ri, fields := range rows {
+		if len(fields) < skip+2 {
+			continue
+		}
+		values := make(map[string]string, len(units))
+		for j := skip + 3; j < len(fields); j += 2 {
+			values[fields[j]] = fields[j-1]
+		}
+		row := append([]string(nil), fields[:skip+2]...)
+		for _, unit := range units {
+			if v, ok := values[unit]; ok {
+				row = append(row, v, unit)
+			} else {
+				row = append(row, "", "")
+			}
+		}
+		// Drop trailing empty fields, in keeping with other short rows.
+		for len(row) > 0 && row[len(row)-1] == "" {
+			row = row[:len(row)-1]
+		}
+		rows[ri] = row
+	}
+}
+
+// benchstatRows collapses rows of `go test -bench` results, after they have
+// been rebuilt by gobenchColumns, so that repeated runs of each benchmark
+// become a single row. For each unit, the row holds the mean of the runs, the
+// variation of the runs as a percentage of the mean, and the unit. The
+// iteration counts are dropped. The first skip fields of each row are kept
+// from the first run.
+func benchstatRows(rows [][]string, skip int) [][]string {
+	type bench struct {
+		prefix []string    // skipped fields and name
+		values [][]float64 // values of each unit
+		digits []int       // fraction digits of each unit
+		units  []string
+	}
+
+	var order []*bench
+	benches := make(map[string]*bench)
+
+	for _, fields := range rows {
+		if len(fields) < skip+2 {
+			continue
+		}
+		name := fields[skip]
+		b, ok := benches[name]
+		if !ok {
+			b = &bench{prefix: append([]string(nil), fields[:skip+1]...)}
+			benches[name] = b
+			order = append(order, b)
+		}
+		for j, u := skip+2, 0; j+1 < len(fields); j, u = j+2, u+1 {
+			for len(b.values) <= u {
+				b.values = append(b.values, nil)
+				b.digits = append(b.digits, 0)
+				b.units = append(b.units, "")
+			}
+			if fields[j+1] != "" {
+				b.units[u] = fields[j+1]
+			}
+			v, ok := parseNumber(fields[j])
+			if !ok {
+				continue
+			}
+			b.values[u] = append(b.values[u], v)
+			if d := fractionDigits(fields[j]); d > b.digits[u] {
+				b.digits[u] = d
+			}
+		}
+	}
+
+	collapsed := make([][]string, 0, len(order))
+	for _, b := range order {
+		row := b.prefix
+		for u, values := range b.values {
+			if len(values) == 0 {
+				row = append(row, "", "", "")
+				continue
+			}
+			mean, variation := meanVariation(values)
+			row = append(row,
+				strconv.FormatFloat(mean, 'f', b.digits[u], 64),
+				fmt.Sprintf("±%.0f%%", variation),
+				b.units[u])
+		}
+		for len(row) > 0 && row[len(row)-1] == "" {
+			row = row[:len(row)-1]
+		}
+		collapsed = append(collapsed, row)
+	}
+
+	return collapsed
+}
+
+// meanVariation returns the mean of values, and their sample standard
+// deviation as a percentage of the mean.
+func meanVariation(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 || mean == 0 {
+		return mean, 0
+	}
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares/float64(len(values)-1)) / math.Abs(mean) * 100
+}
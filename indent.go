@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"io"
+)
+
+// indentWriter writes to w, prefixing each line with prefix.
+type indentWriter struct {
+	w       io.Writer
+	prefix  []byte
+	midline bool // true when the previous write did not end a line
+}
+
+// Write writes p to the underlying writer, inserting the prefix before the
+// first byte of each line.
+func (iw *indentWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		if !iw.midline {
+			if _, err := iw.w.Write(iw.prefix); err != nil {
+				return written, err
+			}
+			iw.midline = true
+		}
+		n := len(p)
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			n = i + 1
+			iw.midline = false
+		}
+		nw, err := iw.w.Write(p[:n])
+		written += nw
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import "strings"
+
+// interner holds a single copy of each distinct field, so identical fields
+// share storage rather than each referring to the line it was read from.
+type interner map[string]string
+
+// intern returns the copy of s held by in, first adding a copy of s which
+// shares no storage with s, when in holds none.
+func (in interner) intern(s string) string {
+	if c, ok := in[s]; ok {
+		return c
+	}
+	var sb strings.Builder
+	sb.WriteString(s)
+	c := sb.String()
+	in[c] = c
+	return c
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/karrick/gobls"
+)
+
+// Long line policies determine what happens to lines longer than the
+// maximum line length.
+const (
+	longTruncate    = "truncate"    // keep the leading bytes of the line
+	longPassthrough = "passthrough" // copy the line unchanged, without aligning it
+	longError       = "error"       // stop at the first long line
+)
+
+// newScanner returns a scanner of the lines read from ior. When the user
+// limits the length of lines, long lines are truncated or rejected as they
+// are read, without ever holding more than the maximum length of them.
+func newScanner(ior io.Reader) gobls.Scanner {
+	if optMaxLineBytes == 0 {
+		return gobls.NewScanner(ior)
+	}
+	return &limitScanner{br: bufio.NewReader(ior), max: int(optMaxLineBytes)}
+}
+
+// unaligned returns true when the line text is copied unchanged among the
+// rows of the table, rather than aligned with them.
+func unaligned(text string) bool {
+	return (optPassthrough != nil && optPassthrough.MatchString(text)) ||
+		(optOnly != nil && !optOnly.MatchString(text)) ||
+		(optMaxLinePolicy == longPassthrough && optMaxLineBytes > 0 && uint64(len(text)) > optMaxLineBytes)
+}
+
+// limitScanner scans lines like the scanners of gobls, applying the long line
+// policy to lines longer than max bytes, not counting their line endings.
+type limitScanner struct {
+	br     *bufio.Reader
+	max    int
+	line   []byte
+	number int // line number of line
+	err    error
+}
+
+func (s *limitScanner) Bytes() []byte { return s.line }
+func (s *limitScanner) Err() error    { return s.err }
+func (s *limitScanner) Text() string  { return string(s.line) }
+
+func (s *limitScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+
+	// Only keep what might be needed of the line: all of it when long lines
+	// pass through, and otherwise enough to tell whether it is too long.
+	s.line = s.line[:0]
+	var long, read bool
+	for {
+		chunk, err := s.br.ReadSlice('\n')
+		read = read || len(chunk) > 0
+		if optMaxLinePolicy == longPassthrough || len(s.line) <= s.max+2 {
+			s.line = append(s.line, chunk...)
+		} else {
+			long = true
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && err != io.EOF {
+			s.err = err
+			return false
+		}
+		if err == io.EOF && !read {
+			return false
+		}
+		break
+	}
+
+	if n := len(s.line); !long && n > 0 && s.line[n-1] == '\n' {
+		s.line = s.line[:n-1]
+		if n > 1 && s.line[n-2] == '\r' {
+			s.line = s.line[:n-2]
+		}
+	}
+	s.number++
+
+	if long || len(s.line) > s.max {
+		switch optMaxLinePolicy {
+		case longError:
+			s.err = fmt.Errorf("line %d is longer than %d bytes", s.number, s.max)
+			return false
+		case longTruncate:
+			n := s.max
+			for n > 0 && !utf8.RuneStart(s.line[n]) {
+				n-- // do not split a character
+			}
+			s.line = s.line[:n]
+		}
+	}
+	return true
+}
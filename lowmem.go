@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/karrick/columnize/pkg/ringbuf"
+)
+
+// lowMemoryChunk is the number of aligned rows rendered at a time by the
+// second pass of --low-memory. It is even, so the striping of rows continues
+// unbroken from one chunk to the next.
+const lowMemoryChunk = 1024
+
+// wholeTable returns true when the options require every row of the table to
+// be held in memory, because they transform the table as a whole, or rows
+// depend upon other rows.
+func wholeTable() bool {
+	return optRagged != raggedPad || optPreset != "" || optAttachUnits ||
+		optAddHeader != nil || optFields != nil || optSelect != nil ||
+		optDrop != nil || optDropMatching != nil ||
+		optMask != nil || optMaskByHeader != nil || optAnonymize != nil ||
+		optNARep != "" || optEmpty != "" || optCompute != nil || optWhere != nil ||
+		optUnique || optUniqueBy > 0 || optGroupBy > 0 || optGroupSeparator > 0 ||
+		optSort != nil || optTake > 0 || optTakeLast > 0 || optSummary != nil ||
+		optAlignExponents || optHumanize || optPrecision >= 0 || optGroupDigits ||
+		optNegativeStyle != "" || optDecimal || optFormatFooter != "" ||
+		optKeepIndent || optWithFilename || optPaginateColumns || optReportWidths ||
+		optDiagnose || optShowExtents || optBetweenStart != nil || optSectionRegex != nil ||
+		optParser == parserPositional
+}
+
+// seekableFile returns the regular file ior reads, when it is one, so that it
+// may be read twice.
+func seekableFile(ior io.Reader) (io.ReadSeeker, bool) {
+	if mf, ok := ior.(*mappedFile); ok {
+		return mf, true
+	}
+	f, ok := ior.(*os.File)
+	if !ok {
+		return nil, false
+	}
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		return nil, false
+	}
+	return f, true
+}
+
+// measurement is what the first pass of --low-memory learns about the rows
+// of a table.
+type measurement struct {
+	lines    int          // number of lines of input
+	widths   []int        // width of the widest field of each column
+	unsigned []int        // width of the widest number without a suffix, plus one
+	numbers  []int        // number of numeric fields of each column
+	texts    []int        // number of non-empty text fields of each column
+	suffixed map[int]bool // columns with negative numbers written with a suffix
+	samples  []string     // leading rows, for choosing a parser
+}
+
+// numeric returns which columns have more numeric fields than non-empty text
+// fields, like numericColumns.
+func (m *measurement) numeric() map[int]bool {
+	numeric := make(map[int]bool)
+	for i := range m.numbers {
+		if m.numbers[i] > m.texts[i] {
+			numeric[i] = true
+		}
+	}
+	return numeric
+}
+
+// measure reads the lines of ior, measuring the rows of the table without
+// keeping them.
+func measure(ior io.Reader) (*measurement, error) {
+	cb := ringbuf.New[string](int(optFooterLines))
+	m := &measurement{suffixed: make(map[int]bool)}
+	headers := optHeaderLines
+
+	br := newScanner(ior)
+	for br.Scan() {
+		m.lines++
+		if headers > 0 {
+			headers--
+			continue
+		}
+		text, ok := cb.QueueDequeue(br.Text())
+		if !ok {
+			continue // may yet be a footer line
+		}
+		if unaligned(text) {
+			continue
+		}
+		if len(m.samples) < parserSample {
+			m.samples = append(m.samples, text)
+		}
+
+		fields := splitFields(text)
+		m.widths = growWidths(m.widths, len(fields))
+		m.numbers = growWidths(m.numbers, len(fields))
+		m.texts = growWidths(m.texts, len(fields))
+		m.unsigned = growWidths(m.unsigned, len(fields))
+		for i, field := range fields {
+			w := displayWidth(field)
+			if w > m.widths[i] {
+				m.widths[i] = w
+			}
+			suffix := hasNegativeSuffix(field)
+			switch {
+			case field == "" || isMissing(field):
+			case isNumeric(field):
+				m.numbers[i]++
+				if !suffix && w+1 > m.unsigned[i] {
+					m.unsigned[i] = w + 1
+				}
+			default:
+				m.texts[i]++
+			}
+			if suffix {
+				m.suffixed[i] = true
+			}
+		}
+	}
+	// Like padSuffixed, leave room after the numbers of suffixed columns.
+	for i := range m.suffixed {
+		if m.unsigned[i] > m.widths[i] {
+			m.widths[i] = m.unsigned[i]
+		}
+	}
+	return m, br.Err()
+}
+
+// processTwoPass aligns the lines of the regular file f, writing them to iow,
+// without holding its rows in memory. The first pass measures the rows, and
+// the second renders them a chunk at a time, padded to the widths of the
+// whole table. Tables that turn out to need the positional parser are read
+// again and aligned as usual.
+func processTwoPass(f io.ReadSeeker, iow io.Writer) error {
+	m, err := measure(f)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if choosePositional(m.samples) {
+		return process(f, iow, "")
+	}
+
+	numeric := m.numeric()
+	title := optTitle // rendered above whatever is rendered first
+	var header, chunk [][]string
+	verbatim := make(map[int]string)
+	var rows int // number of aligned rows in chunk
+
+	flush := func() {
+		if len(header) > 0 {
+			render(iow, layout{lines: header, heads: len(header), unmeasured: len(header), title: title, minimums: m.widths, numeric: numeric, suffixed: m.suffixed})
+			header, title = nil, ""
+		}
+		if len(chunk) > 0 {
+			render(iow, layout{lines: chunk, verbatim: verbatim, title: title, minimums: m.widths, numeric: numeric, suffixed: m.suffixed})
+			chunk, verbatim, rows, title = chunk[:0], make(map[int]string), 0, ""
+		}
+	}
+
+	br := newScanner(f)
+	for number := 1; !isInterrupted() && br.Scan(); number++ {
+		text := br.Text()
+		countLine(text) // rather than while measuring
+		switch {
+		case optHeaderLines > 0:
+			optHeaderLines--
+			if optFormatHeader {
+				header = append(header, splitFields(text))
+			} else if optHeaderStyle != "" {
+				fmt.Fprintf(iow, "%s%s%s\n", optHeaderStyle, text, sgrReset)
+			} else {
+				fmt.Fprintf(iow, "%s\n", text)
+			}
+		case number > m.lines-int(optFooterLines):
+			flush()
+			fmt.Fprintf(iow, "%s\n", text)
+		case unaligned(text):
+			verbatim[len(chunk)] = text
+			chunk = append(chunk, []string{})
+		default:
+			chunk = append(chunk, splitFields(text))
+			if rows++; rows == lowMemoryChunk {
+				flush()
+			}
+		}
+	}
+	flush()
+	return br.Err()
+}
@@ -1,22 +1,58 @@
 package main // import "github.com/karrick/columnize"
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/karrick/gobls"
 	"github.com/karrick/gologs"
 )
 
 var log *gologs.Logger
-var optArgs []string
+
+// output buffers what is written to standard output, and must be flushed
+// before exiting.
+var output *bufio.Writer
+
+// stdout is where output is written, which is output, unless each line is to
+// be prefixed by an indentation.
+var stdout io.Writer
+var optAddHeader, optArgs, optMaskByHeader, optSelect, optSummary []string
 var optDelimiter = " "
-var optFooterLines, optHeaderLines uint64
-var optForce, optLeftJustify, optRightJustify bool
+var optDigitSeparator = ","
+var optPadChar = " "
+var optTitleAlign = "center"
+var optColumnWidths []int
+var optMaxWidth, optMinWidth, optWrap widthList
+var optAggregate []aggregateSpec
+var optColorRules []colorRule
+var optCompute []computed
+var optWhere expression
+var optOverflow policyList
+var optSort []sortKey
+var optColor = colorAuto
+var optNAValues map[string]bool
+var optHumanizeBase = humanizeSI
+var optPaneKey uint64 = 1 // zero when panes have no key column
+var optWriteBuffer uint64 = 64 << 10
+var optParser = parserAuto
+var optPrecision = -1 // negative when numbers are not rounded
+var optRagged = raggedPad
+var optFlushInterval, optTimeout, optWatch time.Duration // zero unless streaming, limited, or watching
+var optAnonymizeSalt, optCPUProfile, optEmpty, optExec, optFilesFrom, optGlob, optFormatFooter, optHeaderStyle, optIndent, optMaxLinePolicy, optMemProfile, optTitle, optNARep, optNegativeStyle, optPreset, optTheme, optTrace, optTruncate string
+var optBetweenEnd, optBetweenStart, optDropMatching, optNumericPattern, optOnly, optPassthrough, optSectionRegex *regexp.Regexp
+var optAnonymize, optDrop, optFields, optLeftColumns, optMask, optRightColumns, optTextColumns columnList
+var optFooterLines, optGroupBy, optGroupSeparator, optHeaderLines, optMaskFirst, optMaskLast, optMaxColumns, optMaxLineBytes, optOutputTabs, optPad, optTake, optTakeLast, optUniqueBy, optWidth uint64
+var optAlignExponents, optAttachUnits, optBenchstat, optCheck, optColorPositive, optColorSign, optCombine, optDecimal, optDelta, optDiagnose, optFit, optFollow, optForce, optFormatHeader, optGroupDigits, optGroupRule, optHumanize, optKeepIndent, optIntern, optKeepNestedIndent, optLowMemory, optNASkip, optNoGlob, optNoTrailingSpace, optNull, optPaginateColumns, optProgress, optRecursive, optReportWidths, optReprintHeader, optSeparate, optShowExtents, optSigFigs, optSortHuman, optStats, optStripe, optTitleUnderline, optUnique, optView, optWithFilename, optWrapFit, optLeftJustify, optRightJustify bool
 
 func help() {
 	// Show detailed help then exit, ignoring other possibly conflicting
@@ -25,18 +61,75 @@ func help() {
 
 Like  'column -t',  but  right  justifies numerical  fields.   Reads input  from
 multiple files  specified on  the command  line or from  standard input  when no
-files are specified.
+files are specified.  Files named by https:// or http:// URLs, and objects named
+by s3:// or gs:// URIs, are fetched, and members of tar and zip archives are
+named like "results.tar.gz::*.out".
 
 SUMMARY:  columnize [options] [file1 [file2 ...]] [options]
+          columnize view [options] [file1 [file2 ...]] [options]
+
+The view subcommand shows the table in an interactive viewer. Keys: j and k, or
+arrows, scroll; space and b page; g and G jump to the top and bottom; h and l
+pan; [ and ] select a column; s sorts by it, again to reverse; x hides it; u
+shows all columns; / searches, n and N find the next and previous match; q
+quits.
 
 USAGE: Not all options  may be used with all other  options.  See below synopsis
 for reference.
 
     columnize [--quiet | [--debug | --force | --verbose]]
-              [--header N]
-              [--delimiter STRING]
+              [--color WHEN] [--theme NAME]
+              [--preset NAME] [--benchstat]
+              [--header N [--format-header]] [--add-header LABELS]
+              [--header-style STYLE]
+              [--fields LIST] [--select NAMES]
+              [--drop LIST] [--drop-matching REGEX]
+              [--mask LIST | --mask-by-header NAMES]
+              [--mask-first N] [--mask-last N]
+              [--anonymize LIST [--anonymize-salt STRING]]
+              [--passthrough REGEX] [--only REGEX] [--between START END]
+              [--section-regex REGEX]
+              [--delimiter STRING] [--pad N] [--pad-char CHAR]
+              [--no-trailing-space] [--output-tabs N]
+              [--keep-indent | --keep-nested-indent] [--indent STRING]
+              [--title STRING [--title-align ALIGN] [--title-underline]]
+              [--align-exponents] [--attach-units] [--decimal]
+              [--precision N [--sig-figs]]
+              [--humanize [--humanize-base BASE]]
+              [--group-digits [--digit-separator STRING]]
+              [--ragged POLICY | --strict]
+              [--max-line-bytes N [--max-line-policy POLICY]]
+              [--parser PARSER] [--max-columns N] [--max-width WIDTHS | --wrap WIDTHS]
+              [--min-width WIDTHS]
+              [--column-widths LIST [--overflow POLICIES]]
+              [--fit [--width N]] [--report-widths] [--diagnose]
+              [--low-memory] [--intern] [--write-buffer SIZE]
+              [--timeout DURATION]
+              [--progress] [--stats] [--cpuprofile FILE] [--memprofile FILE] [--trace FILE]
+              [--paginate-columns [--pane-key COLUMN]]
+              [--files-from FILE [-0 | --null]]
+              [--recursive [--glob PATTERN]] [--no-glob]
+              [--exec COMMAND] [--watch [INTERVAL]] [-f | --follow]
+              [--flush-interval DURATION] [--reprint-header]
+              [--show-extents]
+              [--truncate POSITION]
               [--left | --right]
-              [--footer N]
+              [--left-columns LIST] [--right-columns LIST]
+              [--empty PLACEHOLDER]
+              [--na-values TOKENS [--na-rep STRING] [--na-skip]]
+              [--negative-style STYLE] [--stripe]
+              [--color-rule RULE ...] [--color-sign [--color-positive]]
+              [--numeric-pattern REGEX]
+              [--text-columns LIST]
+              [--compute NAME=EXPRESSION ...] [--where EXPRESSION]
+              [--unique | --unique-by COLUMN]
+              [--group-by COLUMN [--aggregate AGGREGATES]]
+              [--sort KEYS [--sort-human]]
+              [--take N] [--take-last N]
+              [--group-separator COLUMN[:rule]]
+              [--summary AGGREGATES]
+              [--footer N [--format-footer FORMAT]]
+              [--with-filename] [--combine | --separate] | [--delta] | [--check]
               [file1 [file2 ...]]
 
 EXAMPLES:
@@ -47,6 +140,12 @@ EXAMPLES:
     columnize --header 3 --footer 2 testdata/ignore-headers-footers
 
 Command line options:
+  -0, --null
+    With --files-from, file names are separated by NUL characters rather
+    than newlines, as written by 'find -print0'.
+  -f, --follow
+    Keep reading lines appended to the file, like 'tail -f', printing each
+    row as it arrives, aligned with the rows before it.
   --force
     Print errors to stderr, but keep working.
   -h, --help
@@ -55,28 +154,348 @@ Command line options:
     Do not print intermediate errors to stderr.
   -v, --verbose
     Print verbose output to stderr.
+  --version
+    Print the version, the revision built, the build date, and the version
+    of Go, and exit.
+  --add-header labels
+    prepend a header row with the listed labels, e.g., "NAME,COUNT,TIME"
+  --aggregate aggregates (default: count)
+    with --group-by, the aggregates of each group to output, e.g.,
+    "sum(3),count,avg(5)"
+  --align-exponents
+    pad scientific notation so mantissas and exponents line up
+  --anonymize LIST
+    replace each value in the listed columns with a pseudonym derived from
+    it, e.g., "user-3fa2c01d", so equal values remain equal across rows and
+    runs while the values themselves are hidden
+  --anonymize-salt STRING
+    with --anonymize, a secret mixed into each pseudonym, so pseudonyms of
+    guessable values cannot be reproduced by others
+  --attach-units
+    merge units of measure expressed as rates, such as ns/op, B/op, and
+    MB/s, into the number before them, so each value and its unit form a
+    single right justified cell
+  --benchstat
+    collapse repeated 'go test -bench' runs of each benchmark into one row
+    showing the mean and variation of each metric; implies --preset gobench
+  --between start end
+    only align the lines between each line matching the START regex and the
+    following line matching the END regex, copying all other lines unchanged
+  --check
+    rather than aligning files, list those which are not already aligned,
+    and exit with a non-zero status when there are any
+  --color when (default: auto)
+    use color and other styles: always, never, or auto, when writing to a
+    terminal and the NO_COLOR environment variable is empty or not set; may
+    also be given as --color=WHEN
+  --color-positive
+    like --color-sign, but also render positive numbers in green
+  --color-rule rule
+    when writing to a terminal, render fields of a column which satisfy a
+    condition using STYLE, e.g., '3>1000:red' or '5=~FAIL:bold,yellow'; may
+    be given multiple times, and the first matching rule applies
+  --color-sign
+    when writing to a terminal, render negative numbers in red
+  --column-widths list
+    set exact widths for columns, in order, e.g., "12,8,8,30"
+  --combine
+    align the rows of all files together as a single table
+  --compute string
+    append a column named NAME computed from the arithmetic expression, e.g.,
+    'ratio=$3/$2'; may be given multiple times
+  --cpuprofile FILE
+    write a CPU profile of the run to FILE, for 'go tool pprof'
+  --decimal
+    rewrite hexadecimal, octal, and binary integers as decimal
   -d, --delimiter string (default: "  ")
-    output column delimiter
+    output column delimiter, or comma separated list of delimiters following
+    successive columns, e.g., " | , , | "
+  --delta
+    given exactly two files, match rows by first column, and show the
+    absolute and percentage change of each numeric column
+  --diagnose
+    rather than the table, describe how input would be split into fields,
+    how many header and footer lines were found, and the type of each column
+  --digit-separator STRING
+    with --group-digits, the string separating groups of digits; defaults
+    to ","
+  --drop list
+    omit the listed columns, e.g., "2,7"
+  --drop-matching regex
+    omit the columns whose first line labels match REGEX
+  --empty placeholder
+    render empty fields, including those missing from short rows, as
+    PLACEHOLDER, e.g., "-"
+  --exec COMMAND
+    run the command with the shell and align its output rather than reading
+    files, then exit with the command's exit status; with --watch, the
+    command runs again for each redraw
+  --fields list
+    output only the listed columns, in the order listed, e.g., "1,3-5,8"
+  --files-from FILE
+    read the names of the files to format from FILE, or from standard input
+    when FILE is "-", one per line, in addition to those on the command line
+  --fit
+    shrink the widest columns so the table fits the terminal width, as
+    reported by the terminal, or by the COLUMNS environment variable
+  --flush-interval DURATION
+    print the rows read so far, aligned with each other and with the rows
+    before them, each time the duration, e.g., "2s", elapses, rather than
+    after reading all of the input
   --footer int (default: 0)
     ignore N lines from footer when formatting columns
+  --format-footer format
+    align footer lines using the column widths of the table, or as a
+    separate table with their own column widths: table or separate
+  --format-header
+    align header lines with the columns of the table, even when N is 1,
+    without letting them affect column widths or numeric detection
+  --glob PATTERN
+    with --recursive, only format files found in directories whose names
+    match the glob pattern, e.g., "*.out"
+  --group-by int
+    collapse rows sharing the same value in column N into a single row
+  --group-digits
+    write integers of numeric columns with their digits grouped by
+    thousands, such as 1,234,567
+  --group-separator column
+    insert a blank line between adjacent rows whose values in column N
+    differ; append ":rule" for a horizontal rule instead, e.g., "2:rule"
   --header int (default: 0)
-    ignore N lines from header when formatting columns
+    ignore N lines from header when formatting columns; when N is greater
+    than 1, the header lines are aligned with the columns of the table
+  --header-style style
+    when writing to a terminal, render header rows using STYLE, a comma
+    separated list of attributes and colors, e.g., "bold,bg-blue"
+  --humanize
+    write large integers of numeric columns as multiples of powers of the
+    humanize base, such as 1.2M or 34G
+  --humanize-base BASE
+    with --humanize, "si", the default, for powers of 1000 (k, M, G, ...),
+    or "iec" for powers of 1024 (Ki, Mi, Gi, ...)
+  --indent string
+    prefix every line of output with STRING, e.g., "    "
+  --intern
+    keep one copy of each distinct field, rather than each line read,
+    which saves memory when a huge table repeats the same values many times
+  --keep-indent
+    prefix each row with the indentation common to all rows
+  --keep-nested-indent
+    like --keep-indent, but also keep any deeper indentation of each row as
+    part of its first field
   -l, --left
     left-justify all columns
+  --left-columns list
+    left-justify the listed columns, e.g., "2,5-7"
+  --low-memory
+    align each regular file in two passes, measuring its rows and then
+    printing them, rather than holding them all in memory; input that
+    cannot be read twice, or options that transform the whole table, still
+    hold the rows in memory
+  --mask LIST
+    replace the fields in the listed columns, e.g., "3" or "2,5-", with a
+    fixed mask, "****", so tables containing secrets can be shared
+  --mask-by-header NAMES
+    like --mask, but names the columns to mask by their header labels,
+    e.g., "password,token"
+  --mask-first N
+    keep the first N characters of masked fields
+  --mask-last N
+    keep the last N characters of masked fields
+  --max-columns int (default: 0)
+    split at most N columns, the final column keeping the rest of the line
+  --max-line-bytes int (default: 0)
+    longest line, in bytes, read in full, so that minified data or encoded
+    blobs neither exhaust memory nor widen columns absurdly; zero for no limit
+  --max-line-policy policy (default: truncate)
+    what to do with lines longer than --max-line-bytes: truncate them,
+    passthrough them unchanged without aligning them, or error
+  --max-width widths
+    truncate fields wider than the specified width, either for all columns,
+    or per column, e.g., "20,3:40"
+  --memprofile FILE
+    write a memory profile to FILE as the program exits, for 'go tool pprof'
+  --min-width widths
+    pad columns narrower than the specified width, either for all columns,
+    or per column, e.g., "8,1:20"
+  --na-rep string
+    with --na-values, rewrite missing values as STRING
+  --na-skip
+    with --na-values, exclude missing values from summary aggregates,
+    rather than counting them as zero
+  --na-values tokens
+    comma separated list of tokens denoting missing values, which are
+    justified like the rest of their column, e.g., "NA,null,-,N/A"
+  --negative-style string
+    rewrite negative numbers using STYLE: minus, parens, or trailing
+  --no-glob
+    on Windows, do not expand wildcards, e.g., "*.log", in file arguments
+  --no-trailing-space
+    omit the padding after the fields of the final column
+  --numeric-pattern regex
+    fields matching the entirety of REGEX are numeric, rather than those that
+    parse as numbers
+  --only regex
+    only align lines matching REGEX, copying all other lines unchanged, in
+    their original position among the rows of the table
+  --output-tabs int
+    separate columns with tabs rather than the delimiter, assuming tab stops
+    every N display columns
+  --overflow policies (default: truncate)
+    what to do with fields wider than their --column-widths width, either
+    for all columns, or per column: truncate, wrap, or overflow, e.g.,
+    "truncate,4:wrap"
+  --pad int
+    guarantee at least N spaces between columns, regardless of delimiter
+  --pad-char char (default: " ")
+    character used to pad fields to the width of their column
+  --paginate-columns
+    split a table wider than the terminal into panes of the columns that
+    fit, printed one after another, each repeating the pane key column
+  --pane-key COLUMN (default: 1)
+    with --paginate-columns, the column repeated in each pane, or 0 for none
+  --parser PARSER
+    how lines are split into fields: "fields" splits around runs of
+    whitespace; "positional" splits by column extents, runs of character
+    positions where any line has a non-space character, so empty cells of
+    sparse tables stay in their columns; "auto", the default, chooses
+    positional when the first rows do not all have the same number of fields
+  --passthrough regex
+    copy lines matching REGEX unchanged, in their original position among
+    the rows of the table, without letting them affect column widths
+  --precision N
+    round decimal numbers of numeric columns to N decimal places, so numbers
+    of a column have the same number of fraction digits
+  --preset string
+    configure for well known input: gobench, for 'go test -bench' output
+  --progress
+    while reading each file of known size, show how much of it has been
+    read on stderr, when stderr is a terminal
+  --ragged policy (default: pad)
+    handle rows whose number of fields differs from that of the header, or
+    from that of most rows: pad, warn, error, or merge-last, which merges
+    surplus fields into the final column
+  --recursive
+    format the files found by walking each directory argument, in lexical
+    order
+  --report-widths
+    write the inferred type, the narrowest and widest fields, and the chosen
+    width and overflow policy of each column to stderr
+  --reprint-header
+    with --follow or --flush-interval, print the aligned header rows again
+    each time rows widen a column
   -r, --right
     right-justify all columns
+  --right-columns list
+    right-justify the listed columns, e.g., "2,5-7"
+  --section-regex regex
+    treat lines matching REGEX as rulers between sections, aligning each
+    section independently, and rendering each ruler to span its section
+  --select names
+    output only the columns whose first line labels match the listed names,
+    in the order listed, ignoring case and allowing globs, e.g., "NAME,RATE*"
+  --separate
+    align the rows of each file independently, which is the default
+  --show-extents
+    rather than the table, print the character range of each column extent
+    found by merging runs of non-space characters across lines, and mark the
+    extents above a sample of the input
+  --sig-figs
+    with --precision, round decimal numbers to N significant digits instead
+  --sort keys
+    sort rows by the listed columns, each optionally followed by a direction,
+    numerically when the column is numeric, e.g., "3:desc,1:asc"; sizes and
+    durations are only sorted by value with --sort-human
+  --sort-human
+    when sorting, summarizing, and aggregating, read the values of sizes,
+    e.g., "4.0K", "1.2G", and the "1.2k" of --humanize, or of durations,
+    e.g., "150ms" and "1h30m"
+  --stats
+    print statistics of the run to stderr once done: the files, lines, and
+    bytes read, the rows and columns aligned, the widest column, the elapsed
+    time, and the peak memory used
+  --strict
+    report each row whose number of fields differs from that of the header,
+    or from that of most rows, then exit with a non-zero status
+  --stripe
+    when writing to a terminal, color the background of alternate rows
+  --summary aggregates
+    append rows with the listed aggregates of each numeric column: sum, avg,
+    min, max, or count, e.g., "sum,avg,max"
+  --take int
+    keep only the first N rows of the table, not counting header lines
+  --take-last int
+    keep only the last N rows of the table, not counting header lines
+  --text-columns list
+    never treat fields in the listed columns as numeric, e.g., "1,4"
+  --theme name
+    style output using the theme file NAME.toml in the columnize/themes
+    directory of the user configuration directory, or at the path NAME
+  --timeout duration
+    give up, and exit with an error, when reading and formatting the input
+    takes longer than the duration, such as "30s", so that a hung command,
+    server, or pipe does not hang this program
+  --title string
+    render STRING as a title above the table
+  --title-align align (default: center)
+    align the title over the table: center or left
+  --title-underline
+    underline the title
+  --trace FILE
+    write an execution trace of the run to FILE, for 'go tool trace'
+  --truncate string (default: right)
+    remove characters from the right, left, or middle of truncated fields
+  --unique
+    omit rows which duplicate an earlier row
+  --unique-by int
+    omit rows whose value in column N duplicates that of an earlier row
+  --watch [INTERVAL] (default: 2s)
+    clear the screen and redraw the table whenever an input file changes,
+    and every INTERVAL, a duration such as "500ms" or a number of seconds
+  --where expression
+    only output rows for which the expression is true, e.g.,
+    '$3 > 1000 && $1 != "total"'
+  --width int (default: terminal width)
+    shrink the widest columns so the table fits N columns; implies --fit
+  --with-filename
+    prefix each row with the name of its file; implies --combine, unless
+    --separate is provided
+  --wrap widths
+    word wrap fields wider than the specified width onto continuation lines,
+    either for all columns, or per column, e.g., "20,3:40"; "auto" shrinks
+    the widest columns like --fit, wrapping rather than truncating them
+  --write-buffer int (default: 65536)
+    size in bytes of the buffer holding output until it is written; output
+    is also written as streamed rows are flushed, and before exiting
 `)
 	os.Exit(0)
 }
 
-func init() {
-	// Process command line arguments and configure logging.
+// parseArgs processes the command line arguments and configures logging,
+// exiting when the arguments are invalid.
+func parseArgs() {
 	var optDebug, optQuiet, optVerbose bool
 	var errs []error
 	var err error
 
+	// The view subcommand precedes any options.
+	first := 1
+	if len(os.Args) > 1 && os.Args[1] == "view" {
+		optView = true
+		first = 2
+	}
+
+	// The color policy may also be joined to its option by an equal sign, as
+	// in --color=auto, the form other tools accept.
+	for ai := first; ai < len(os.Args) && os.Args[ai] != "--"; ai++ {
+		if value := strings.TrimPrefix(os.Args[ai], "--color="); value != os.Args[ai] {
+			os.Args = append(os.Args[:ai], append([]string{"--color", value}, os.Args[ai+1:]...)...)
+			ai++
+		}
+	}
+
 argLoop:
-	for ai, am := 1, len(os.Args)-1; ai <= am; ai++ {
+	for ai, am := first, len(os.Args)-1; ai <= am; ai++ {
 		switch os.Args[ai] {
 		case "-":
 			optArgs = append(optArgs, os.Args[ai]) // solitary hyphen: implies standard input
@@ -84,49 +503,850 @@ argLoop:
 			// double hyphen: append remaining arguments to optArgs
 			optArgs = append(optArgs, os.Args[ai+1:]...)
 			break argLoop
+		case "--add-header":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optAddHeader = strings.Split(os.Args[ai], ",")
+			for i, label := range optAddHeader {
+				optAddHeader[i] = strings.TrimSpace(label)
+			}
+		case "--aggregate":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optAggregate, err = parseAggregateSpecs(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as aggregate list: %s", os.Args[ai-1], err))
+			}
+		case "--align-exponents":
+			optAlignExponents = true
+		case "--anonymize":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optAnonymize, err = parseColumnList(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as column list: %s", os.Args[ai-1], err))
+			}
+		case "--anonymize-salt":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optAnonymizeSalt = os.Args[ai]
+		case "--attach-units":
+			optAttachUnits = true
+		case "--benchstat":
+			optBenchstat = true
+			optPreset = presetGobench
+		case "--between":
+			if ai+1 >= am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			if optBetweenStart, err = regexp.Compile(os.Args[ai+1]); err != nil {
+				errs = append(errs, fmt.Errorf("cannot compile option argument for %q as regular expression: %s", os.Args[ai], err))
+			}
+			if optBetweenEnd, err = regexp.Compile(os.Args[ai+2]); err != nil {
+				errs = append(errs, fmt.Errorf("cannot compile option argument for %q as regular expression: %s", os.Args[ai], err))
+			}
+			ai += 2
+		case "--check":
+			optCheck = true
+		case "--color":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			switch optColor = os.Args[ai]; optColor {
+			case colorAlways, colorAuto, colorNever:
+			default:
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as color policy: %q", os.Args[ai-1], os.Args[ai]))
+			}
+		case "--color-positive":
+			optColorPositive = true
+			optColorSign = true
+		case "--color-rule":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			cr, err := parseColorRule(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as color rule: %s", os.Args[ai-1], err))
+				continue
+			}
+			optColorRules = append(optColorRules, cr)
+		case "--color-sign":
+			optColorSign = true
+		case "--column-widths":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optColumnWidths, err = parseFixedWidths(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as width list: %s", os.Args[ai-1], err))
+			}
+		case "--combine":
+			optCombine = true
+		case "--compute":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			c, err := parseComputed(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as computed column: %s", os.Args[ai-1], err))
+				continue
+			}
+			optCompute = append(optCompute, c)
+		case "--cpuprofile":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optCPUProfile = os.Args[ai]
 		case "--debug":
 			optDebug = true
+		case "--decimal":
+			optDecimal = true
 		case "--delimiter":
 			if ai == am {
 				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
 				continue
 			}
 			ai++
-			optDelimiter = os.Args[ai]
-		case "--footer":
+			optDelimiter = os.Args[ai]
+		case "--delta":
+			optDelta = true
+		case "--diagnose":
+			optDiagnose = true
+		case "--digit-separator":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			if optDigitSeparator = os.Args[ai]; optDigitSeparator == "" {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as digit separator: %q", os.Args[ai-1], os.Args[ai]))
+			}
+		case "--drop":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optDrop, err = parseColumnList(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as column list: %s", os.Args[ai-1], err))
+			}
+		case "--drop-matching":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optDropMatching, err = regexp.Compile(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot compile option argument for %q as regular expression: %s", os.Args[ai-1], err))
+			}
+		case "--empty":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optEmpty = os.Args[ai]
+		case "--exec":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optExec = os.Args[ai]
+		case "--fields":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optFields, err = parseColumnList(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as column list: %s", os.Args[ai-1], err))
+			}
+		case "--files-from":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optFilesFrom = os.Args[ai]
+		case "--fit":
+			optFit = true
+		case "--flush-interval":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optFlushInterval, err = time.ParseDuration(os.Args[ai+1])
+			if err != nil || optFlushInterval <= 0 {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as positive duration: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--follow":
+			optFollow = true
+		case "--footer":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optFooterLines, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as unsigned integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--force":
+			optForce = true
+		case "--format-footer":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			switch optFormatFooter = os.Args[ai]; optFormatFooter {
+			case footerSeparate, footerTable:
+			default:
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as footer format: %q", os.Args[ai-1], os.Args[ai]))
+			}
+		case "--format-header":
+			optFormatHeader = true
+		case "--glob":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optGlob = os.Args[ai]
+		case "--group-by":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optGroupBy, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil || optGroupBy == 0 {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as positive integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--group-digits":
+			optGroupDigits = true
+		case "--group-separator":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			arg := os.Args[ai]
+			if i := strings.IndexByte(arg, ':'); i >= 0 {
+				switch arg[i+1:] {
+				case "blank":
+				case "rule":
+					optGroupRule = true
+				default:
+					errs = append(errs, fmt.Errorf("cannot parse option argument for %q as separator: %q", os.Args[ai-1], arg[i+1:]))
+					continue
+				}
+				arg = arg[:i]
+			}
+			optGroupSeparator, err = strconv.ParseUint(arg, 10, 64)
+			if err != nil || optGroupSeparator == 0 {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as positive integer: %q", os.Args[ai-1], arg))
+			}
+		case "--header":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optHeaderLines, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as unsigned integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--header-style":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optHeaderStyle, err = parseStyle(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as style: %s", os.Args[ai-1], err))
+			}
+		case "--help":
+			help()
+		case "--humanize":
+			optHumanize = true
+		case "--humanize-base":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			switch optHumanizeBase = os.Args[ai]; optHumanizeBase {
+			case humanizeIEC, humanizeSI:
+			default:
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as humanize base: %q", os.Args[ai-1], os.Args[ai]))
+			}
+		case "--indent":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optIndent = os.Args[ai]
+		case "--intern":
+			optIntern = true
+		case "--keep-indent":
+			optKeepIndent = true
+		case "--keep-nested-indent":
+			optKeepIndent = true
+			optKeepNestedIndent = true
+		case "--left":
+			optLeftJustify = true
+		case "--left-columns":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optLeftColumns, err = parseColumnList(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as column list: %s", os.Args[ai-1], err))
+			}
+		case "--low-memory":
+			optLowMemory = true
+		case "--mask":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optMask, err = parseColumnList(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as column list: %s", os.Args[ai-1], err))
+			}
+		case "--mask-by-header":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optMaskByHeader = strings.Split(os.Args[ai], ",")
+		case "--mask-first":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optMaskFirst, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as unsigned integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--mask-last":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optMaskLast, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as unsigned integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--max-columns":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optMaxColumns, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as unsigned integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--max-line-bytes":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optMaxLineBytes, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as unsigned integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--max-line-policy":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			switch optMaxLinePolicy = os.Args[ai]; optMaxLinePolicy {
+			case longError, longPassthrough, longTruncate:
+			default:
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as long line policy: %q", os.Args[ai-1], os.Args[ai]))
+			}
+		case "--max-width":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optMaxWidth, err = parseWidthList(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as width list: %s", os.Args[ai-1], err))
+			}
+		case "--memprofile":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optMemProfile = os.Args[ai]
+		case "--min-width":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optMinWidth, err = parseWidthList(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as width list: %s", os.Args[ai-1], err))
+			}
+		case "--na-rep":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optNARep = os.Args[ai]
+		case "--na-skip":
+			optNASkip = true
+		case "--na-values":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optNAValues = make(map[string]bool)
+			for _, token := range strings.Split(os.Args[ai], ",") {
+				optNAValues[strings.TrimSpace(token)] = true
+			}
+		case "--negative-style":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			switch optNegativeStyle = os.Args[ai]; optNegativeStyle {
+			case "minus", "parens", "trailing":
+			default:
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as negative style: %q", os.Args[ai-1], os.Args[ai]))
+			}
+		case "--no-glob":
+			optNoGlob = true
+		case "--no-trailing-space":
+			optNoTrailingSpace = true
+		case "--null":
+			optNull = true
+		case "--numeric-pattern":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			// Anchor the pattern so it must match the entire field.
+			optNumericPattern, err = regexp.Compile("^(?:" + os.Args[ai] + ")$")
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot compile option argument for %q as regular expression: %s", os.Args[ai-1], err))
+			}
+		case "--only":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optOnly, err = regexp.Compile(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot compile option argument for %q as regular expression: %s", os.Args[ai-1], err))
+			}
+		case "--output-tabs":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optOutputTabs, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil || optOutputTabs == 0 {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as positive integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--overflow":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optOverflow, err = parsePolicyList(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as overflow policy list: %s", os.Args[ai-1], err))
+			}
+		case "--pad":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optPad, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as unsigned integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--pad-char":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			if optPadChar = os.Args[ai]; displayWidth(optPadChar) != 1 {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as single character: %q", os.Args[ai-1], os.Args[ai]))
+			}
+		case "--paginate-columns":
+			optPaginateColumns = true
+		case "--pane-key":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optPaneKey, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as unsigned integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--parser":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			switch optParser = os.Args[ai]; optParser {
+			case parserAuto, parserFields, parserPositional:
+			default:
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as parser: %q", os.Args[ai-1], os.Args[ai]))
+			}
+		case "--passthrough":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optPassthrough, err = regexp.Compile(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot compile option argument for %q as regular expression: %s", os.Args[ai-1], err))
+			}
+		case "--precision":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			precision, err := strconv.ParseUint(os.Args[ai+1], 10, 8)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as unsigned integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			optPrecision = int(precision)
+			ai++
+		case "--preset":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			switch optPreset = os.Args[ai]; optPreset {
+			case presetGobench:
+			default:
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as preset: %q", os.Args[ai-1], os.Args[ai]))
+			}
+		case "--progress":
+			optProgress = true
+		case "--quiet":
+			optQuiet = true
+		case "--ragged":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			switch optRagged = os.Args[ai]; optRagged {
+			case raggedError, raggedMergeLast, raggedPad, raggedWarn:
+			default:
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as ragged policy: %q", os.Args[ai-1], os.Args[ai]))
+			}
+		case "--recursive":
+			optRecursive = true
+		case "--report-widths":
+			optReportWidths = true
+		case "--reprint-header":
+			optReprintHeader = true
+		case "--right":
+			optRightJustify = true
+		case "--right-columns":
 			if ai == am {
 				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
 				continue
 			}
-			optFooterLines, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			ai++
+			optRightColumns, err = parseColumnList(os.Args[ai])
 			if err != nil {
-				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as unsigned integer: %q", os.Args[ai], os.Args[ai+1]))
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as column list: %s", os.Args[ai-1], err))
+			}
+		case "--section-regex":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
 				continue
 			}
 			ai++
-		case "--force":
-			optForce = true
-		case "--header":
+			optSectionRegex, err = regexp.Compile(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot compile option argument for %q as regular expression: %s", os.Args[ai-1], err))
+			}
+		case "--select":
 			if ai == am {
 				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
 				continue
 			}
-			optHeaderLines, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			ai++
+			optSelect = strings.Split(os.Args[ai], ",")
+		case "--separate":
+			optSeparate = true
+		case "--show-extents":
+			optShowExtents = true
+		case "--sig-figs":
+			optSigFigs = true
+		case "--sort":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optSort, err = parseSortKeys(os.Args[ai])
 			if err != nil {
-				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as unsigned integer: %q", os.Args[ai], os.Args[ai+1]))
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as sort keys: %s", os.Args[ai-1], err))
+			}
+		case "--sort-human":
+			optSortHuman = true
+		case "--stats":
+			optStats = true
+		case "--strict":
+			optRagged = raggedStrict
+		case "--stripe":
+			optStripe = true
+		case "--summary":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optSummary, err = parseAggregates(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as aggregate list: %s", os.Args[ai-1], err))
+			}
+		case "--take":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optTake, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil || optTake == 0 {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as positive integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--take-last":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optTakeLast, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil || optTakeLast == 0 {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as positive integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--text-columns":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optTextColumns, err = parseColumnList(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as column list: %s", os.Args[ai-1], err))
+			}
+		case "--theme":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optTheme = os.Args[ai]
+		case "--timeout":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optTimeout, err = time.ParseDuration(os.Args[ai+1])
+			if err != nil || optTimeout <= 0 {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as positive duration: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
+		case "--title":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optTitle = os.Args[ai]
+		case "--title-align":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			switch optTitleAlign = os.Args[ai]; optTitleAlign {
+			case "center", "left":
+			default:
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as title alignment: %q", os.Args[ai-1], os.Args[ai]))
+			}
+		case "--title-underline":
+			optTitleUnderline = true
+		case "--trace":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optTrace = os.Args[ai]
+		case "--truncate":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			switch optTruncate = os.Args[ai]; optTruncate {
+			case "left", "middle", "right":
+			default:
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as truncate position: %q", os.Args[ai-1], os.Args[ai]))
+			}
+		case "--unique":
+			optUnique = true
+		case "--unique-by":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optUniqueBy, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil || optUniqueBy == 0 {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as positive integer: %q", os.Args[ai], os.Args[ai+1]))
 				continue
 			}
 			ai++
-		case "--help":
-			help()
-		case "--left":
-			optLeftJustify = true
-		case "--quiet":
-			optQuiet = true
-		case "--right":
-			optRightJustify = true
 		case "--verbose":
 			optVerbose = true
+		case "--version":
+			printVersion()
+		case "--watch":
+			optWatch = defaultWatchInterval
+			if ai < am {
+				// The interval is optional, either a duration, such as "500ms",
+				// or a number of seconds.
+				if d, err := time.ParseDuration(os.Args[ai+1]); err == nil && d > 0 {
+					optWatch = d
+					ai++
+				} else if f, err := strconv.ParseFloat(os.Args[ai+1], 64); err == nil && f > 0 {
+					optWatch = time.Duration(f * float64(time.Second))
+					ai++
+				}
+			}
+		case "--where":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			optWhere, err = compileExpression(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as expression: %s", os.Args[ai-1], err))
+			}
+		case "--width":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optWidth, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as unsigned integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			optFit = true
+			ai++
+		case "--with-filename":
+			optWithFilename = true
+		case "--wrap":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			ai++
+			if os.Args[ai] == "auto" {
+				optWrapFit = true
+				continue
+			}
+			optWrap, err = parseWidthList(os.Args[ai])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as width list: %s", os.Args[ai-1], err))
+			}
+		case "--write-buffer":
+			if ai == am {
+				errs = append(errs, fmt.Errorf("option missing required argument: %q", os.Args[ai]))
+				continue
+			}
+			optWriteBuffer, err = strconv.ParseUint(os.Args[ai+1], 10, 64)
+			if err != nil || optWriteBuffer == 0 {
+				errs = append(errs, fmt.Errorf("cannot parse option argument for %q as positive integer: %q", os.Args[ai], os.Args[ai+1]))
+				continue
+			}
+			ai++
 		default:
 			if os.Args[ai][0] != '-' {
 				optArgs = append(optArgs, os.Args[ai]) // this argument is not an option
@@ -134,6 +1354,8 @@ argLoop:
 			}
 			for aii, ail := 1, len(os.Args[ai]); aii < ail; aii++ {
 				switch os.Args[ai][aii] {
+				case '0':
+					optNull = true
 				case 'd': // delimiter
 					switch {
 					case ail-aii > 1:
@@ -145,6 +1367,8 @@ argLoop:
 						errs = append(errs, fmt.Errorf("option missing required argument: \"-%c\"", os.Args[ai][aii]))
 					}
 					continue argLoop // already sucked up the rest of this argument
+				case 'f':
+					optFollow = true
 				case 'h':
 					help()
 				case 'l':
@@ -169,6 +1393,113 @@ argLoop:
 		os.Exit(1)
 	}
 
+	output = bufio.NewWriterSize(os.Stdout, int(optWriteBuffer))
+	stdout = output
+	if optIndent != "" {
+		stdout = &indentWriter{w: output, prefix: []byte(optIndent)}
+	}
+
+	delimiters = parseDelimiters(optDelimiter)
+	if optPad > 0 {
+		for i, d := range delimiters {
+			delimiters[i] = padDelimiter(d, int(optPad))
+		}
+	}
+
+	if optNARep != "" && optNAValues != nil {
+		// Normalized missing values must still be recognized as missing.
+		optNAValues[optNARep] = true
+	}
+
+	if !optNoGlob {
+		optArgs = expandWildcards(optArgs)
+	}
+	if optFilesFrom != "" {
+		ctx, cancel := timeoutContext()
+		files, err := readFileList(ctx, optFilesFrom, optNull)
+		cancel()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		optArgs = append(optArgs, files...)
+	}
+	if optRecursive {
+		files, err := expandDirectories(optArgs, optGlob)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		optArgs = files
+	} else if optGlob != "" {
+		errs = append(errs, fmt.Errorf("cannot use --glob without --recursive"))
+	}
+	if optMaxLinePolicy == "" {
+		optMaxLinePolicy = longTruncate
+	} else if optMaxLineBytes == 0 {
+		errs = append(errs, fmt.Errorf("cannot use --max-line-policy without --max-line-bytes"))
+	}
+	if optExec != "" && len(optArgs) > 0 {
+		errs = append(errs, fmt.Errorf("cannot use both --exec and files"))
+	}
+	if optFollow && len(optArgs) > 1 {
+		errs = append(errs, fmt.Errorf("cannot follow more than one file"))
+	}
+	if optFollow || optFlushInterval > 0 {
+		for _, name := range streamConflicts() {
+			errs = append(errs, fmt.Errorf("cannot use %s with --follow or --flush-interval, because rows are printed as they are read", name))
+		}
+	}
+	if optAggregate != nil && optGroupBy == 0 {
+		errs = append(errs, fmt.Errorf("cannot use --aggregate without --group-by"))
+	}
+	if optSigFigs && optPrecision < 1 {
+		errs = append(errs, fmt.Errorf("cannot use --sig-figs without --precision of at least 1"))
+	}
+	if optCombine && optSeparate {
+		errs = append(errs, fmt.Errorf("cannot use both --combine and --separate"))
+	}
+	if optCheck && optCombine {
+		errs = append(errs, fmt.Errorf("cannot use both --check and --combine, because each file is checked on its own"))
+	}
+	if optBetweenStart != nil {
+		for _, name := range regionConflicts() {
+			errs = append(errs, fmt.Errorf("cannot use %s with --between, because regions of each file are aligned on their own", name))
+		}
+	}
+	if optSectionRegex != nil {
+		for _, name := range regionConflicts() {
+			errs = append(errs, fmt.Errorf("cannot use %s with --section-regex, because sections of each file are aligned on their own", name))
+		}
+	}
+	if optWithFilename && !optSeparate && !optCheck {
+		optCombine = true
+	}
+
+	// Multiple header lines always form a block aligned with the rest of the
+	// table, while a single header line is only aligned upon request.
+	if optHeaderLines > 1 {
+		optFormatHeader = true
+	}
+
+	if optTheme != "" {
+		if styles, err = loadTheme(optTheme); err != nil {
+			errs = append(errs, fmt.Errorf("cannot load theme: %s", err))
+		}
+		if optHeaderStyle == "" {
+			optHeaderStyle = styles.header
+		}
+	}
+
+	// Unless requested, styles are only for terminals, lest escape sequences
+	// end up in files. Files being checked never contain them.
+	if optView {
+		// The viewer shows the header rows above the rows it scrolls, and
+		// pans and highlights lines of plain text.
+		optFormatHeader = true
+	}
+	if useColor = colorPolicy(optColor) && !optCheck && !optView; !useColor {
+		optHeaderStyle = ""
+	}
+
 	if optQuiet {
 		if optDebug {
 			errs = append(errs, fmt.Errorf("cannot use both --quiet and --debug"))
@@ -205,26 +1536,157 @@ argLoop:
 }
 
 func main() {
-	err := forEachFile(optArgs, func(r io.Reader, w io.Writer) error {
-		return process(r, os.Stdout)
-	})
+	runStats.start = time.Now()
+	parseArgs()
+	if err := startProfiles(); err != nil {
+		log.Error("%s", err)
+		exit(1)
+	}
+	handleSignals()
+	ctx, cancel := timeoutContext()
+	defer cancel()
+
+	var err error
+	finished := true
+
+	if optView {
+		// Only reading the table is limited by the timeout, not viewing it.
+		err = viewFiles(ctx, optArgs)
+	} else {
+		finished, err = untilDone(ctx, func() error {
+			if optWatch > 0 {
+				return watch(ctx, optArgs, optWatch)
+			}
+			if optFollow || optFlushInterval > 0 {
+				return forEachFile(ctx, optArgs, func(name string, r io.Reader, w io.Writer) error {
+					if optFollow && name != stdinName && optExec == "" {
+						r = &followReader{ctx: ctx, r: r}
+					}
+					return stream(r, w, optFlushInterval)
+				})
+			}
+			return run(ctx)
+		})
+	}
+	if finished {
+		// Output is only flushed when nothing may still be writing it.
+		if ferr := output.Flush(); err == nil {
+			err = ferr
+		}
+	}
+	if optStats {
+		writeStats(os.Stderr)
+	}
+
+	if isInterrupted() {
+		// What was read before the user interrupted was written, and errors
+		// of commands killed along with it are of no interest.
+		exit(signalStatus(caught))
+	}
+	if isBrokenPipe(err) {
+		// The reader of the output, such as a pager, has quit.
+		exit(0)
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", optTimeout)
+	} else if ee, ok := err.(*exec.ExitError); ok {
+		// The command already reported its own errors.
+		exit(exitStatus(ee))
+	}
 	if err != nil {
 		log.Error("%s", err)
-		os.Exit(1)
+		exit(1)
+	}
+	exit(0) // writing any profiles
+}
+
+// run formats the input files, or standard input when there are none,
+// according to the options.
+func run(ctx context.Context) error {
+	var err error
+
+	if optDelta {
+		if len(optArgs) != 2 {
+			err = errDeltaFiles
+		} else {
+			err = deltaFiles(ctx, optArgs[0], optArgs[1], stdout)
+		}
+	} else if optCombine {
+		// Rows of all files are aligned together as a single table.
+		var t *table
+		if t, err = newTable(); err == nil {
+			err = forEachFile(ctx, optArgs, func(name string, r io.Reader, w io.Writer) error {
+				if optDiagnose || optShowExtents {
+					w = ioutil.Discard
+				}
+				return t.read(r, w, filenameField(name))
+			})
+			if err == nil && optShowExtents {
+				err = t.showExtents(stdout)
+			} else if err == nil && optDiagnose {
+				err = t.diagnose(stdout)
+			} else if err == nil {
+				err = t.write(stdout)
+			}
+		}
+	} else if optCheck {
+		var unaligned bool
+		if unaligned, err = checkFiles(ctx, optArgs, stdout); err == nil && unaligned {
+			output.Flush()
+			exit(1)
+		}
+	} else {
+		err = forEachFile(ctx, optArgs, func(name string, r io.Reader, w io.Writer) error {
+			return processFile(r, stdout, name)
+		})
 	}
+
+	return err
 }
 
-// forEachFile invokes callback for each file in files. When files is empty, it
-// reads from standard input.
-func forEachFile(files []string, callback func(io.Reader, io.Writer) error) error {
+// stdinName is the name given to standard input when reporting file names.
+const stdinName = "(standard input)"
+
+// forEachFile invokes callback for each file in files, along with its name.
+// When files is empty, it reads from standard input, and when the user runs a
+// command, it reads the command's output instead.
+func forEachFile(ctx context.Context, files []string, callback func(string, io.Reader, io.Writer) error) error {
+	read := callback
+	callback = func(name string, r io.Reader, w io.Writer) error {
+		countFile()
+		return read(name, r, w)
+	}
+
+	if optExec != "" {
+		return execCommand(ctx, optExec, func(r io.Reader) error {
+			return callback(optExec, r, stdout)
+		})
+	}
 	if len(files) == 0 {
-		return callback(os.Stdin, os.Stdout)
+		return callback(stdinName, os.Stdin, stdout)
 	}
 
 	for _, file := range files {
-		err := withOpenFile(file, func(f io.Reader) error {
-			return callback(f, os.Stdout)
-		})
+		if err := ctx.Err(); err != nil {
+			return err // even when forced, as no further file could be read
+		}
+		if isInterrupted() {
+			break
+		}
+		name := file
+		if name == "-" {
+			name = stdinName
+		}
+		var err error
+		if archive, pattern, ok := splitArchive(file); ok {
+			err = withArchiveMembers(ctx, archive, pattern, func(member string, r io.Reader) error {
+				return callback(archive+archiveSeparator+member, r, stdout)
+			})
+		} else {
+			err = withOpenFile(ctx, file, func(f io.Reader) error {
+				return callback(name, f, stdout)
+			})
+		}
 		if err != nil {
 			if !optForce {
 				return err
@@ -236,14 +1698,32 @@ func forEachFile(files []string, callback func(io.Reader, io.Writer) error) erro
 	return nil
 }
 
-func withOpenFile(path string, callback func(io.Reader) error) (err error) {
+func withOpenFile(ctx context.Context, path string, callback func(io.Reader) error) (err error) {
 	if path == "-" {
 		return callback(os.Stdin)
 	}
+	if archive, pattern, ok := splitArchive(path); ok {
+		return withArchiveMembers(ctx, archive, pattern, func(_ string, r io.Reader) error {
+			return callback(r)
+		})
+	}
 
-	var fh *os.File
+	var fh io.ReadCloser
 
-	fh, err = os.Open(path)
+	if isCloudURI(path) {
+		fh, err = openCloud(ctx, path)
+	} else if isURL(path) {
+		fh, err = openURL(ctx, path)
+	} else {
+		var f *os.File
+		if f, err = os.Open(path); err == nil {
+			fh = f
+			if mf, ok := openMapped(f); ok {
+				f.Close() // the mapping remains after the file is closed
+				fh = mf
+			}
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -259,87 +1739,81 @@ func withOpenFile(path string, callback func(io.Reader) error) (err error) {
 	return
 }
 
-func process(ior io.Reader, iow io.Writer) error {
-	// Use a cirular buffer, so we are processing the Nth previous line.
-	cb, err := newTailBuffer(optFooterLines)
-	if err != nil {
-		return err
+// filenameField returns the field prefixed to each row read from the file
+// with the specified name, which is the empty string for no field unless
+// --with-filename is provided.
+func filenameField(name string) string {
+	if optWithFilename {
+		return name
 	}
+	return ""
+}
 
-	var lines [][]string
-	widths := make(map[int]int, 16) // pre-allocate 16 columns
-
-	br := gobls.NewScanner(ior)
-
-	for br.Scan() {
-		if optHeaderLines > 0 {
-			// Only need to count lines while ignoring headers.
-			fmt.Fprintf(iow, "%s\n", br.Text())
-			optHeaderLines--
-			continue
+// processFile aligns the lines read from ior, which were read from the file
+// with the specified name, writing them to iow.
+func processFile(ior io.Reader, iow io.Writer, name string) error {
+	if optLowMemory && !wholeTable() {
+		if f, ok := seekableFile(ior); ok {
+			return processTwoPass(f, iow)
 		}
+	}
+	if optBetweenStart != nil {
+		return processBetween(ior, iow)
+	}
+	if optSectionRegex != nil {
+		return processSections(ior, iow, filenameField(name))
+	}
+	return process(ior, iow, filenameField(name))
+}
 
-		line := cb.QueueDequeue(br.Text())
-		if line == nil {
-			// NOTE: A circular buffer always gives us Nth previous line. So
-			// this fills up the circular queue with N items, which we will
-			// process after the queue fills.
-			continue
+// process aligns the lines read from ior, writing them to iow. When name is
+// not empty, it is prepended to each row as its own field.
+func process(ior io.Reader, iow io.Writer, name string) error {
+	t, err := newTable()
+	if err != nil {
+		return err
+	}
+	if optDiagnose || optShowExtents {
+		if err = t.read(ior, ioutil.Discard, name); err != nil {
+			return err
 		}
-
-		fields := strings.Fields(line.(string))
-		for i, field := range fields {
-			if width := len(field); width > widths[i] { // if width wider than previous width
-				widths[i] = width // save this width as new widest width for this column
-			}
+		if optShowExtents {
+			return t.showExtents(iow)
 		}
-		lines = append(lines, fields)
+		return t.diagnose(iow)
 	}
-	if err := br.Err(); err != nil {
+	if err = t.read(ior, iow, name); err != nil {
 		return err
 	}
+	return t.write(iow)
+}
 
-	// All input has been read (and header has even been printed). Pretty print
-	// all lines collected thus far, remembering that there may be N lines left
-	// in the circular buffer remaining to be processed.
-	for _, line := range lines {
-		d := optDelimiter
-		for i := 0; i < len(line); i++ {
-			// Print newline instead of delimiter for final column.
-			if i == len(line)-1 {
-				d = "\n"
-			}
-
-			field := line[i]
-			width := widths[i]
-
-			if optLeftJustify {
-				left(iow, width, field, d)
-			} else if optRightJustify {
-				right(iow, width, field, d)
-			} else {
-				// Right justify if column is a number; otherwise left justify.
-				if _, err := strconv.ParseFloat(field, 64); err == nil {
-					right(iow, width, field, d)
-				} else {
-					left(iow, width, field, d)
-				}
-			}
-		}
+// readFileList returns the file names listed in the file at path, or in
+// standard input when path is "-", separated by newlines, or by NUL
+// characters when null is true. Empty names are ignored.
+func readFileList(ctx context.Context, path string, null bool) ([]string, error) {
+	var buf []byte
+	err := withOpenFile(ctx, path, func(r io.Reader) error {
+		var err error
+		buf, err = ioutil.ReadAll(r)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file names: %s", err)
 	}
 
-	// Dump remaining contents of circular buffer.
-	for _, line := range cb.Drain() {
-		fmt.Fprintf(iow, "%s\n", line.(string))
+	separator := "\n"
+	if null {
+		separator = "\x00"
 	}
-
-	return nil
-}
-
-func left(iow io.Writer, width int, field, delimiter string) {
-	fmt.Fprintf(iow, "%-*s%s", width, field, delimiter)
-}
-
-func right(iow io.Writer, width int, field, delimiter string) {
-	fmt.Fprintf(iow, "%*s%s", width, field, delimiter)
+	var files []string
+	for _, name := range strings.Split(string(buf), separator) {
+		if !null {
+			name = strings.TrimSuffix(name, "\r")
+		}
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// mask is the fixed string that replaces the masked characters of a field.
+const mask = "****"
+
+// maskColumns replaces the fields of rows in the columns with the specified
+// indexes, which may be listed more than once, with masked versions of
+// themselves.
+func maskColumns(rows [][]string, indexes []int) {
+	masked := make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		masked[i] = true
+	}
+	for _, fields := range rows {
+		for i, field := range fields {
+			if masked[i] {
+				fields[i] = maskField(field, int(optMaskFirst), int(optMaskLast))
+			}
+		}
+	}
+}
+
+// maskField returns field with all but its first and last characters
+// replaced by a fixed mask. Fields too short to hide anything while showing
+// those characters are replaced entirely. Empty fields remain empty.
+func maskField(field string, first, last int) string {
+	if field == "" {
+		return ""
+	}
+	runes := []rune(field)
+	if first+last >= len(runes) {
+		return mask
+	}
+	return string(runes[:first]) + mask + string(runes[len(runes)-last:])
+}
+
+// anonymizeColumns replaces the fields of rows in the columns with the
+// specified indexes with pseudonyms derived from their values, so equal
+// values have equal pseudonyms, across rows as well as invocations. Each
+// pseudonym is prefixed by the column's label in header, when provided.
+func anonymizeColumns(rows [][]string, indexes []int, header []string) {
+	prefixes := make(map[int]string, len(indexes))
+	for _, i := range indexes {
+		prefixes[i] = "anon"
+		if i < len(header) && header[i] != "" {
+			prefixes[i] = strings.ToLower(header[i])
+		}
+	}
+	for _, fields := range rows {
+		for i, field := range fields {
+			if prefix, ok := prefixes[i]; ok && field != "" && !isMissing(field) {
+				fields[i] = prefix + "-" + pseudonym(field, optAnonymizeSalt)
+			}
+		}
+	}
+}
+
+// pseudonymBytes is the number of bytes of the digest kept in a pseudonym,
+// enough that distinct values are unlikely to share one even among millions
+// of them, which with four bytes is likely once there are tens of thousands.
+const pseudonymBytes = 8
+
+// pseudonym returns a short hexadecimal digest of value, keyed by salt.
+func pseudonym(value, salt string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	io.WriteString(mac, value)
+	return hex.EncodeToString(mac.Sum(nil)[:pseudonymBytes])
+}
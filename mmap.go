@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// mmapThreshold is the size of the smallest regular file that is mapped into
+// memory rather than read.
+const mmapThreshold = 1 << 20
+
+// mappedFile is the contents of a regular file mapped into memory, which may
+// be read like any other file, or have its lines sliced directly out of the
+// mapping, without first being copied into a buffer.
+type mappedFile struct {
+	*bytes.Reader
+	data []byte
+}
+
+// openMapped returns the contents of f mapped into memory, when f is a
+// regular file large enough to be worth mapping, and mapping is supported.
+// Otherwise it returns false, and f ought to be read as usual.
+func openMapped(f *os.File) (*mappedFile, bool) {
+	if optFollow {
+		return nil, false // appended data would not be seen
+	}
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() || fi.Size() < mmapThreshold || int64(int(fi.Size())) != fi.Size() {
+		return nil, false
+	}
+	data, err := mapFile(f, int(fi.Size()))
+	if err != nil {
+		return nil, false
+	}
+	return &mappedFile{Reader: bytes.NewReader(data), data: data}, true
+}
+
+// Close unmaps the file, after which neither it nor any slice of its lines
+// may be used.
+func (mf *mappedFile) Close() error {
+	return unmapFile(mf.data)
+}
+
+// eachLine calls callback with each line not yet read, without its line
+// ending, in the same way gobls scans lines, then marks the file read.
+func (mf *mappedFile) eachLine(callback func([]byte)) {
+	rest := mf.data[len(mf.data)-mf.Len():]
+	for len(rest) > 0 {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			i = len(rest)
+		}
+		line := rest[:i]
+		if i < len(rest) {
+			rest = rest[i+1:]
+		} else {
+			rest = nil
+		}
+		if n := len(line); n > 0 && line[n-1] == '\r' {
+			line = line[:n-1]
+		}
+		callback(line)
+	}
+	mf.Seek(0, io.SeekEnd)
+}
@@ -0,0 +1,20 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile returns an error, because files are not mapped into memory on this
+// platform.
+func mapFile(f *os.File, size int) ([]byte, error) {
+	return nil, errors.New("cannot map files into memory on this platform")
+}
+
+// unmapFile does nothing, because files are not mapped on this platform.
+func unmapFile(data []byte) error {
+	return nil
+}
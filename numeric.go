@@ -0,0 +1,334 @@
+package main
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// isNumeric returns true when field ought to be treated as a number for the
+// purpose of justification. When the user provides a numeric pattern, it
+// alone decides which fields are numeric.
+func isNumeric(field string) bool {
+	if optNumericPattern != nil {
+		return optNumericPattern.MatchString(field)
+	}
+	if optHumanize && humanized.MatchString(field) {
+		return true
+	}
+	if optGroupDigits && isGrouped(field) {
+		return true
+	}
+	_, ok := parseNumber(field)
+	return ok
+}
+
+// parseNumber returns the numerical value of field, and whether field is a
+// number at all. When the user attaches units, a field of a number followed
+// by its unit has the value of the number.
+func parseNumber(field string) (float64, bool) {
+	if optAttachUnits {
+		field = detachUnit(field)
+	}
+	// Cheaply rule out most text by its first byte, before the costlier
+	// parsing below, which allocates an error for each field that fails.
+	if field == "" {
+		return 0, false
+	}
+	switch c := field[0]; {
+	case c >= '0' && c <= '9', c == '.', c == '+', c == '-', c == '(':
+	case c == 'i', c == 'I', c == 'n', c == 'N': // Inf and NaN
+	default:
+		return 0, false
+	}
+	if maybeFloat(field) {
+		if f, err := strconv.ParseFloat(field, 64); err == nil {
+			return f, true
+		}
+	}
+	if i, ok := parseBasePrefixed(field); ok {
+		f, _ := new(big.Float).SetInt(i).Float64()
+		return f, true
+	}
+	if !strings.ContainsAny(field, ",()") && field[len(field)-1] != '-' {
+		return 0, false // not in accounting notation, so ParseFloat decided
+	}
+	if magnitude, negative, ok := splitAccounting(field); ok {
+		if f, err := strconv.ParseFloat(strings.Replace(magnitude, ",", "", -1), 64); err == nil {
+			if negative {
+				f = -f
+			}
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// maybeFloat returns false when the bytes of field alone show ParseFloat would
+// reject it, such as those of dates, times, and network addresses, so that
+// it need not allocate an error saying so.
+func maybeFloat(field string) bool {
+	var dots int
+	for i := 0; i < len(field); i++ {
+		switch field[i] {
+		case '.':
+			if dots++; dots > 1 {
+				return false
+			}
+		case '+', '-':
+			if i > 0 && !strings.ContainsRune("eEpP", rune(field[i-1])) {
+				return false
+			}
+		case ',', ':', '/', '(', ')':
+			return false
+		}
+	}
+	return true
+}
+
+// grouped matches an unsigned decimal number, optionally with its integer
+// digits grouped by thousands separators.
+var grouped = regexp.MustCompile(`^(?:[0-9]{1,3}(?:,[0-9]{3})+|[0-9]+)(?:\.[0-9]*)?$`)
+
+// splitAccounting returns the unsigned magnitude of field, and whether field
+// is negative, when field is a number written in one of the notations common
+// in financial reports: with its integer digits grouped by commas, such as
+// 1,234.56, and negative numbers either wrapped in parentheses, such as
+// (1,234.56), or followed by a minus sign, such as 1,234.56-.
+func splitAccounting(field string) (string, bool, bool) {
+	var negative bool
+	switch l := len(field); {
+	case l > 2 && field[0] == '(' && field[l-1] == ')':
+		field, negative = field[1:l-1], true
+	case l > 1 && field[l-1] == '-':
+		field, negative = field[:l-1], true
+	}
+	if strings.IndexByte(field, ',') < 0 || !grouped.MatchString(field) {
+		// Also allow unsigned numbers ParseFloat accepts, such as 1.5e3.
+		if field == "" || !strings.ContainsRune("0123456789.", rune(field[0])) {
+			return "", false, false
+		}
+		if _, err := strconv.ParseFloat(field, 64); err != nil {
+			return "", false, false
+		}
+	}
+	return field, negative, true
+}
+
+// hasNegativeSuffix returns true when field is a negative number written with
+// a closing parenthesis or trailing minus sign.
+func hasNegativeSuffix(field string) bool {
+	if l := len(field); l > 0 && (field[l-1] == ')' || field[l-1] == '-') {
+		_, negative, ok := splitAccounting(field)
+		return ok && negative
+	}
+	return false
+}
+
+// suffixedColumns returns which columns have at least one negative number
+// written with a closing parenthesis or trailing minus sign, so that the other
+// numbers in those columns may be padded to keep their digits aligned.
+func suffixedColumns(lines [][]string) map[int]bool {
+	suffixed := make(map[int]bool)
+	for _, fields := range lines {
+		for i, field := range fields {
+			if hasNegativeSuffix(field) {
+				suffixed[i] = true
+			}
+		}
+	}
+	return suffixed
+}
+
+// padSuffixed widens each of the suffixed columns of lines, so that its
+// numbers without a suffix fit followed by a space, which keeps their digits
+// aligned with those of the negative numbers written with a suffix.
+func padSuffixed(widths []int, lines [][]string, suffixed map[int]bool) {
+	if len(suffixed) == 0 {
+		return
+	}
+	for _, fields := range lines {
+		for i, field := range fields {
+			if suffixed[i] && isNumeric(field) && !hasNegativeSuffix(field) {
+				if width := displayWidth(field) + 1; width > widths[i] {
+					widths[i] = width
+				}
+			}
+		}
+	}
+}
+
+// isMissing returns true when field is one of the tokens declared to denote a
+// missing value, or the placeholder for empty fields.
+func isMissing(field string) bool {
+	return optNAValues[field] || (optEmpty != "" && field == optEmpty)
+}
+
+// numericColumns returns which columns of rows have more numeric fields than
+// non-empty text fields, where missing values count as empty.
+func numericColumns(rows [][]string) map[int]bool {
+	var numbers, texts []int
+	for _, fields := range rows {
+		for len(numbers) < len(fields) {
+			numbers = append(numbers, 0)
+			texts = append(texts, 0)
+		}
+		for i, field := range fields {
+			switch {
+			case field == "" || isMissing(field):
+			case isNumeric(field):
+				numbers[i]++
+			default:
+				texts[i]++
+			}
+		}
+	}
+	numeric := make(map[int]bool)
+	for i := range numbers {
+		if numbers[i] > texts[i] {
+			numeric[i] = true
+		}
+	}
+	return numeric
+}
+
+// isNumericColumn returns true when most of the non-empty fields in the column
+// with index i of rows are numeric, or have a value read by value, unless the
+// user declares the column to hold text.
+func isNumericColumn(rows [][]string, i int, value func(string) (float64, bool)) bool {
+	if optTextColumns.contains(i) {
+		return false
+	}
+	var numbers, texts int
+	for _, fields := range rows {
+		if i >= len(fields) || fields[i] == "" || isMissing(fields[i]) {
+			continue
+		}
+		if _, ok := value(fields[i]); ok || isNumeric(fields[i]) {
+			numbers++
+		} else {
+			texts++
+		}
+	}
+	return numbers > texts
+}
+
+// restyleNegative returns field rewritten in the specified negative number
+// style when field is a negative decimal number; otherwise it returns field
+// unchanged. The style is one of "minus", "parens", or "trailing".
+func restyleNegative(field, style string) string {
+	var magnitude string
+
+	if l := len(field); l > 1 && field[0] == '-' {
+		if _, err := strconv.ParseFloat(field, 64); err != nil {
+			return field
+		}
+		magnitude = field[1:]
+	} else {
+		var negative, ok bool
+		if magnitude, negative, ok = splitAccounting(field); !ok || !negative {
+			return field
+		}
+	}
+
+	switch style {
+	case "parens":
+		return "(" + magnitude + ")"
+	case "trailing":
+		return magnitude + "-"
+	default:
+		return "-" + magnitude
+	}
+}
+
+// parseBasePrefixed returns the value of field when it is an integer literal
+// with an explicit hexadecimal, octal, or binary base prefix, such as 0x1f8b,
+// 0o755, or 0b1010. Integers with a leading zero but no base letter are not
+// considered, because zero padded decimal numbers are far more common in
+// tabular output than legacy octal literals.
+func parseBasePrefixed(field string) (*big.Int, bool) {
+	digits := field
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		digits = digits[1:]
+	}
+	if len(digits) < 3 || digits[0] != '0' {
+		return nil, false
+	}
+	switch digits[1] {
+	case 'b', 'B', 'o', 'O', 'x', 'X':
+	default:
+		return nil, false
+	}
+	// SetString with base 0 honors the prefix, the sign, and underscores
+	// between digits.
+	return new(big.Int).SetString(field, 0)
+}
+
+// normalizeBase returns field rewritten as a decimal integer when it is a base
+// prefixed integer literal; otherwise it returns field unchanged.
+func normalizeBase(field string) string {
+	if i, ok := parseBasePrefixed(field); ok {
+		return i.String()
+	}
+	return field
+}
+
+// scientific matches a number in scientific notation, capturing the mantissa
+// integer digits, the mantissa fraction digits, the exponent marker, the
+// exponent sign, and the exponent digits.
+var scientific = regexp.MustCompile(`^([-+]?[0-9]+)(?:\.([0-9]*))?([eE])([-+]?)([0-9]+)$`)
+
+// alignExponents rewrites the fields in scientific notation in each column so
+// that every such field in a column has the same number of mantissa fraction
+// digits and exponent digits, and always shows the exponent sign. Padding is
+// done with zeros, so each rewritten field still represents the same value,
+// and when right justified, both the decimal points and the exponent markers
+// of a column line up.
+func alignExponents(lines [][]string) {
+	var fracWidths, expWidths []int
+
+	for _, fields := range lines {
+		for i, field := range fields {
+			m := scientific.FindStringSubmatch(field)
+			if m == nil {
+				continue
+			}
+			for len(fracWidths) <= i {
+				fracWidths = append(fracWidths, 0)
+				expWidths = append(expWidths, 0)
+			}
+			if len(m[2]) > fracWidths[i] {
+				fracWidths[i] = len(m[2])
+			}
+			if len(m[5]) > expWidths[i] {
+				expWidths[i] = len(m[5])
+			}
+		}
+	}
+
+	for _, fields := range lines {
+		for i, field := range fields {
+			m := scientific.FindStringSubmatch(field)
+			if m == nil {
+				continue
+			}
+			var sb strings.Builder
+			sb.WriteString(m[1])
+			if fracWidths[i] > 0 {
+				sb.WriteByte('.')
+				sb.WriteString(m[2])
+				sb.WriteString(strings.Repeat("0", fracWidths[i]-len(m[2])))
+			}
+			sb.WriteString(m[3])
+			if m[4] == "" {
+				sb.WriteByte('+')
+			} else {
+				sb.WriteString(m[4])
+			}
+			sb.WriteString(strings.Repeat("0", expWidths[i]-len(m[5])))
+			sb.WriteString(m[5])
+			fields[i] = sb.String()
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		field string
+		want  float64
+		ok    bool
+	}{
+		// decimal numbers
+		{field: "0", want: 0, ok: true},
+		{field: "42", want: 42, ok: true},
+		{field: "-42", want: -42, ok: true},
+		{field: "+3.5", want: 3.5, ok: true},
+		{field: ".5", want: 0.5, ok: true},
+		{field: "0755", want: 755, ok: true},
+		{field: "1.5e3", want: 1500, ok: true},
+		{field: "2E-2", want: 0.02, ok: true},
+		{field: "0x1p-2", want: 0.25, ok: true},
+
+		// base prefixed integers
+		{field: "0x1f", want: 31, ok: true},
+		{field: "0X1F", want: 31, ok: true},
+		{field: "0o755", want: 493, ok: true},
+		{field: "0b1010", want: 10, ok: true},
+		{field: "-0x10", want: -16, ok: true},
+		{field: "+0b11", want: 3, ok: true},
+		{field: "0x_ff", want: 255, ok: true},
+		{field: "0xffffffffffffffffffff", want: 0xffffffffffffffffffff, ok: true},
+		{field: "0x"},
+		{field: "0xg"},
+		{field: "0b102"},
+
+		// accounting notation
+		{field: "1,234", want: 1234, ok: true},
+		{field: "12,345,678.9", want: 12345678.9, ok: true},
+		{field: "(12)", want: -12, ok: true},
+		{field: "(1,234.56)", want: -1234.56, ok: true},
+		{field: "(1.5e3)", want: -1500, ok: true},
+		{field: "12-", want: -12, ok: true},
+		{field: "1,234.56-", want: -1234.56, ok: true},
+		{field: "1,23"},
+		{field: "12,3456"},
+		{field: "()"},
+		{field: "(abc)"},
+		{field: "(-12)"},
+		{field: "12--"},
+
+		// special values
+		{field: "Inf", want: math.Inf(1), ok: true},
+		{field: "-inf", want: math.Inf(-1), ok: true},
+		{field: "+Infinity", want: math.Inf(1), ok: true},
+		{field: "Info"},
+		{field: "none"},
+
+		// dates, times, and network addresses
+		{field: "2024-05-01"},
+		{field: "05/01/2024"},
+		{field: "12:34:56"},
+		{field: "1-2"},
+		{field: "192.168.1.1"},
+		{field: "10.0.0.1"},
+		{field: "fe80::1"},
+		{field: "::1"},
+
+		// text
+		{field: ""},
+		{field: "-"},
+		{field: "+"},
+		{field: "."},
+		{field: "abc"},
+		{field: "1.2.3"},
+		{field: "12abc"},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseNumber(tt.field)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("parseNumber(%q) = %v, %t; want %v, %t", tt.field, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	for _, field := range []string{"NaN", "nan", "NAN"} {
+		if got, ok := parseNumber(field); !ok || !math.IsNaN(got) {
+			t.Errorf("parseNumber(%q) = %v, %t; want NaN, true", field, got, ok)
+		}
+	}
+}
+
+func TestNormalizeBase(t *testing.T) {
+	tests := []struct {
+		field, want string
+	}{
+		{"0x1f8b", "8075"},
+		{"0o755", "493"},
+		{"0b1010", "10"},
+		{"-0x10", "-16"},
+		{"0xffffffffffffffffffff", "1208925819614629174706175"},
+		{"0755", "0755"},
+		{"0x", "0x"},
+		{"12", "12"},
+		{"abc", "abc"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeBase(tt.field); got != tt.want {
+			t.Errorf("normalizeBase(%q) = %q; want %q", tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestSplitAccounting(t *testing.T) {
+	tests := []struct {
+		field, magnitude string
+		negative, ok     bool
+	}{
+		{field: "1,234.56", magnitude: "1,234.56", ok: true},
+		{field: "(1,234.56)", magnitude: "1,234.56", negative: true, ok: true},
+		{field: "1,234.56-", magnitude: "1,234.56", negative: true, ok: true},
+		{field: "(12)", magnitude: "12", negative: true, ok: true},
+		{field: "12-", magnitude: "12", negative: true, ok: true},
+		{field: "1.5e3", magnitude: "1.5e3", ok: true},
+		{field: "1,23"},
+		{field: "(1,234.56"},
+		{field: "-"},
+		{field: "()"},
+		{field: "(x)"},
+	}
+
+	for _, tt := range tests {
+		magnitude, negative, ok := splitAccounting(tt.field)
+		if magnitude != tt.magnitude || negative != tt.negative || ok != tt.ok {
+			t.Errorf("splitAccounting(%q) = %q, %t, %t; want %q, %t, %t", tt.field, magnitude, negative, ok, tt.magnitude, tt.negative, tt.ok)
+		}
+	}
+}
+
+func TestNumericColumns(t *testing.T) {
+	defer func(values map[string]bool) { optNAValues = values }(optNAValues)
+	optNAValues = map[string]bool{"NA": true}
+
+	rows := [][]string{
+		{"alpha", "1,234", "2024-05-01", "10", "NA", "0x1f"},
+		{"beta", "(56)", "2024-05-02", "x", "NA", "12-"},
+		{"gamma", "", "2024-05-03", "y", "3", "7"},
+		{"delta", "78-"},
+	}
+	want := map[int]bool{1: true, 4: true, 5: true}
+
+	got := numericColumns(rows)
+	for i := 0; i < 6; i++ {
+		if got[i] != want[i] {
+			t.Errorf("numericColumns column %d = %t; want %t", i+1, got[i], want[i])
+		}
+	}
+}
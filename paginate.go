@@ -0,0 +1,92 @@
+package main
+
+import "io"
+
+// paginate renders the table described by l in panes no wider than the
+// terminal, one after another, each with the columns that fit beside the key
+// column, so every row of each pane remains identifiable by its key. Options
+// naming particular columns, such as per column widths, count the columns of
+// each pane, rather than those of the table.
+func paginate(iow io.Writer, l layout) {
+	lines := l.lines
+	widths := columnWidths(lines[l.unmeasured : len(lines)-l.footers])
+	panes := columnPanes(widths, columnCount(lines), int(optPaneKey)-1)
+	if len(panes) < 2 {
+		render(iow, l)
+		return
+	}
+	countTable(len(lines)-l.heads-l.footers-len(l.verbatim), growWidths(widths, columnCount(lines)))
+
+	for pi, indexes := range panes {
+		if pi > 0 {
+			io.WriteString(iow, "\n")
+		}
+		pane := l
+		pane.uncounted = true // counted whole above
+		pane.lines = make([][]string, len(lines))
+		for li, fields := range lines {
+			if fields == nil {
+				continue // a rule
+			}
+			row := make([]string, len(indexes))
+			for i, index := range indexes {
+				if index < len(fields) {
+					row[i] = fields[index]
+				}
+			}
+			pane.lines[li] = row
+		}
+		pane.signed = make(map[int]bool, len(l.signed))
+		for i, index := range indexes {
+			pane.signed[i] = l.signed[index]
+		}
+		if pi > 0 {
+			pane.title = ""
+		}
+		render(iow, pane)
+	}
+}
+
+// columnPanes returns the indexes of the n columns, which have the specified
+// widths, grouped into panes no wider than the terminal. Each pane starts with
+// the key column, unless key is out of range, followed by as many of the
+// remaining columns as fit, but at least one.
+func columnPanes(widths []int, n, key int) [][]int {
+	widths = growWidths(widths, n)
+	width := int(optWidth)
+	if width == 0 {
+		width = terminalWidth()
+	}
+	space := displayWidth(delimiter(0))
+
+	var panes [][]int
+	var pane []int
+	var used int
+	start := func() {
+		pane, used = nil, 0
+		if key >= 0 && key < n {
+			pane, used = []int{key}, widths[key]
+		}
+	}
+	start()
+	keys := len(pane)
+
+	for i := 0; i < n; i++ {
+		if i == key {
+			continue
+		}
+		if len(pane) > keys && used+space+widths[i] > width {
+			panes = append(panes, pane)
+			start()
+		}
+		if len(pane) > 0 {
+			used += space
+		}
+		pane = append(pane, i)
+		used += widths[i]
+	}
+	if len(pane) > keys || panes == nil {
+		panes = append(panes, pane)
+	}
+	return panes
+}
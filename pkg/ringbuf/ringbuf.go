@@ -0,0 +1,81 @@
+// Package ringbuf provides a circular buffer holding the N most recently
+// queued items, such as the trailing lines of a stream, which are only known
+// not to be among the last N once N more have been read.
+package ringbuf
+
+// Buffer is a non-concurrency safe circular buffer holding up to N of the
+// most recently queued items. The zero value holds no items, so each queued
+// item is immediately dequeued.
+type Buffer[T any] struct {
+	items  []T
+	index  int
+	looped bool
+}
+
+// New returns a Buffer holding up to n items.
+func New[T any](n int) *Buffer[T] {
+	if n <= 0 {
+		return new(Buffer[T])
+	}
+	return &Buffer[T]{items: make([]T, n)}
+}
+
+// QueueDequeue stores item, and returns the item queued N items before it,
+// which it no longer holds. It returns false rather than an item until N
+// items have been queued.
+func (b *Buffer[T]) QueueDequeue(item T) (T, bool) {
+	// A buffer without capacity holds nothing, so item is dequeued at once.
+	if b.items == nil {
+		return item, true
+	}
+
+	// Swap item previously stored at index with new item.
+	prev, ok := b.items[b.index], b.looped
+	b.items[b.index] = item
+
+	// Increment index making note whether it wraps.
+	if b.index++; b.index == len(b.items) {
+		b.index = 0
+		b.looped = true
+	}
+
+	return prev, ok
+}
+
+// Peek returns the item the next call to QueueDequeue will return, without
+// dequeuing it, or false when that call will not return an item.
+func (b *Buffer[T]) Peek() (T, bool) {
+	if b.items == nil || !b.looped {
+		var zero T
+		return zero, false
+	}
+	return b.items[b.index], true
+}
+
+// Len returns the number of items held.
+func (b *Buffer[T]) Len() int {
+	if b.looped {
+		return len(b.items)
+	}
+	return b.index
+}
+
+// Drain returns a copy of the items held, from the oldest to the most recently
+// queued, which later use of the buffer does not change. The items remain
+// held until Reset is called.
+func (b *Buffer[T]) Drain() []T {
+	items := make([]T, 0, b.Len())
+	if b.looped {
+		items = append(items, b.items[b.index:]...) // f g c d e
+	}
+	return append(items, b.items[:b.index]...) // a b c _ _
+}
+
+// Reset empties the buffer, keeping its capacity.
+func (b *Buffer[T]) Reset() {
+	var zero T
+	for i := range b.items {
+		b.items[i] = zero
+	}
+	b.index, b.looped = 0, false
+}
@@ -0,0 +1,171 @@
+package ringbuf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueueDequeue(t *testing.T) {
+	b := New[int](3)
+
+	t.Run("filling", func(t *testing.T) {
+		for i := 1; i <= 3; i++ {
+			if got, ok := b.QueueDequeue(i); ok {
+				t.Errorf("QueueDequeue(%d) = %d, true; want false", i, got)
+			}
+		}
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		// Each item is dequeued three items after it was queued, through
+		// several wraps of the buffer.
+		for i := 4; i <= 10; i++ {
+			got, ok := b.QueueDequeue(i)
+			if !ok || got != i-3 {
+				t.Errorf("QueueDequeue(%d) = %d, %t; want %d, true", i, got, ok, i-3)
+			}
+		}
+	})
+}
+
+func TestPeek(t *testing.T) {
+	b := New[string](2)
+
+	if got, ok := b.Peek(); ok {
+		t.Errorf("Peek() of empty buffer = %q, true; want false", got)
+	}
+	b.QueueDequeue("a")
+	b.QueueDequeue("b")
+
+	for _, item := range []string{"c", "d", "e"} {
+		want, ok := b.Peek()
+		if !ok {
+			t.Fatalf("Peek() of full buffer = false; want true")
+		}
+		if got, _ := b.QueueDequeue(item); got != want {
+			t.Errorf("QueueDequeue(%q) = %q; want %q, as peeked", item, got, want)
+		}
+	}
+}
+
+func TestLen(t *testing.T) {
+	b := New[int](3)
+
+	for i, want := range []int{1, 2, 3, 3, 3} {
+		b.QueueDequeue(i)
+		if got := b.Len(); got != want {
+			t.Errorf("Len() after %d items = %d; want %d", i+1, got, want)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	b := New[int](3)
+	for i := 0; i < 5; i++ {
+		b.QueueDequeue(i)
+	}
+
+	b.Reset()
+
+	if got := b.Len(); got != 0 {
+		t.Errorf("Len() after Reset = %d; want 0", got)
+	}
+	if got, ok := b.Peek(); ok {
+		t.Errorf("Peek() after Reset = %d, true; want false", got)
+	}
+	if got := b.Drain(); len(got) != 0 {
+		t.Errorf("Drain() after Reset = %v; want none", got)
+	}
+	// The buffer keeps its capacity, and fills again from empty.
+	for i := 0; i < 3; i++ {
+		if got, ok := b.QueueDequeue(i); ok {
+			t.Errorf("QueueDequeue(%d) after Reset = %d, true; want false", i, got)
+		}
+	}
+	if got, ok := b.QueueDequeue(3); !ok || got != 0 {
+		t.Errorf("QueueDequeue(3) after Reset = %d, %t; want 0, true", got, ok)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	tests := []struct {
+		name  string
+		items int
+		want  []int
+	}{
+		{"empty", 0, []int{}},
+		{"partial", 2, []int{0, 1}},
+		{"full", 3, []int{0, 1, 2}},
+		{"wrapped", 5, []int{2, 3, 4}},
+		{"wrapped twice", 7, []int{4, 5, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New[int](3)
+			for i := 0; i < tt.items; i++ {
+				b.QueueDequeue(i)
+			}
+			if got := b.Drain(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Drain() = %v; want %v", got, tt.want)
+			}
+			// Items remain held until Reset.
+			if got := b.Len(); got != len(tt.want) {
+				t.Errorf("Len() after Drain = %d; want %d", got, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestDrainAliasing(t *testing.T) {
+	// Whether or not the buffer has wrapped, the items drained are a copy,
+	// which neither later items, nor appending to them, nor Reset change.
+	for _, items := range []int{2, 5} {
+		b := New[int](3)
+		for i := 0; i < items; i++ {
+			b.QueueDequeue(i)
+		}
+		drained := b.Drain()
+		want := append([]int(nil), drained...)
+
+		_ = append(drained, -1)
+		b.QueueDequeue(100)
+		b.QueueDequeue(101)
+		b.Reset()
+
+		if !reflect.DeepEqual(drained, want) {
+			t.Errorf("after %d items, Drain() = %v changed to %v", items, want, drained)
+		}
+		if got := b.Drain(); len(got) != 0 {
+			t.Errorf("after %d items, Drain() after Reset = %v; want none", items, got)
+		}
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	check := func(t *testing.T, b *Buffer[string]) {
+		t.Helper()
+		if got, ok := b.QueueDequeue("a"); !ok || got != "a" {
+			t.Errorf("QueueDequeue(%q) = %q, %t; want %q, true", "a", got, ok, "a")
+		}
+		if got, ok := b.Peek(); ok {
+			t.Errorf("Peek() = %q, true; want false", got)
+		}
+		if got := b.Len(); got != 0 {
+			t.Errorf("Len() = %d; want 0", got)
+		}
+		if got := b.Drain(); len(got) != 0 {
+			t.Errorf("Drain() = %v; want none", got)
+		}
+		b.Reset()
+	}
+
+	t.Run("zero value", func(t *testing.T) {
+		var b Buffer[string]
+		check(t, &b)
+	})
+
+	t.Run("no capacity", func(t *testing.T) {
+		check(t, New[string](0))
+	})
+}
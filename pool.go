@@ -0,0 +1,54 @@
+package main
+
+import "sync"
+
+// Slices that are only needed briefly, such as the fields of rows that are
+// streamed, the batches of lines being scanned, and the lines being rendered,
+// are returned to these pools once used, so long running streams reuse them
+// rather than continually allocating new ones for the garbage collector.
+var (
+	batchPool  = sync.Pool{New: func() interface{} { s := make([]sourceLine, 0, readBatch); return &s }}
+	bufferPool = sync.Pool{New: func() interface{} { return new([]byte) }}
+	fieldsPool = sync.Pool{New: func() interface{} { return new([]string) }}
+)
+
+// getBatch returns an empty batch of scanned lines, with room for readBatch
+// lines.
+func getBatch() []sourceLine {
+	return (*batchPool.Get().(*[]sourceLine))[:0]
+}
+
+// putBatch returns batch to its pool, after which it must no longer be used.
+func putBatch(batch []sourceLine) {
+	for i := range batch {
+		batch[i] = sourceLine{} // do not keep lines alive
+	}
+	batch = batch[:0]
+	batchPool.Put(&batch)
+}
+
+// getFields returns an empty slice, to which fields may be appended.
+func getFields() []string {
+	return (*fieldsPool.Get().(*[]string))[:0]
+}
+
+// putFields returns fields to its pool, after which it must no longer be
+// used.
+func putFields(fields []string) {
+	for i := range fields {
+		fields[i] = ""
+	}
+	fields = fields[:0]
+	fieldsPool.Put(&fields)
+}
+
+// getBuffer returns an empty buffer, to which bytes may be appended.
+func getBuffer() []byte {
+	return (*bufferPool.Get().(*[]byte))[:0]
+}
+
+// putBuffer returns buf to its pool, after which it must no longer be used.
+func putBuffer(buf []byte) {
+	buf = buf[:0]
+	bufferPool.Put(&buf)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// stopProfiles stop the profiles the user requested, writing what remains of
+// them to their files.
+var stopProfiles []func() error
+
+// startProfiles starts the CPU profile and execution trace the user requests,
+// and creates the file of the memory profile, which is written when the
+// program exits, so that a file that cannot be created is reported before
+// doing any work.
+func startProfiles() error {
+	if optCPUProfile != "" {
+		f, err := os.Create(optCPUProfile)
+		if err != nil {
+			return fmt.Errorf("cannot create CPU profile: %s", err)
+		}
+		if err = pprof.StartCPUProfile(f); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("cannot start CPU profile: %s", err)
+		}
+		stopProfiles = append(stopProfiles, func() error {
+			pprof.StopCPUProfile()
+			return f.Close()
+		})
+	}
+
+	if optTrace != "" {
+		f, err := os.Create(optTrace)
+		if err != nil {
+			return fmt.Errorf("cannot create trace: %s", err)
+		}
+		if err = trace.Start(f); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("cannot start trace: %s", err)
+		}
+		stopProfiles = append(stopProfiles, func() error {
+			trace.Stop()
+			return f.Close()
+		})
+	}
+
+	if optMemProfile != "" {
+		f, err := os.Create(optMemProfile)
+		if err != nil {
+			return fmt.Errorf("cannot create memory profile: %s", err)
+		}
+		stopProfiles = append(stopProfiles, func() error {
+			runtime.GC() // so the profile includes the latest allocations
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				_ = f.Close()
+				return err
+			}
+			return f.Close()
+		})
+	}
+
+	return nil
+}
+
+// exit stops the profiles the user requested, writing them, then exits the
+// program with status.
+func exit(status int) {
+	for _, stop := range stopProfiles {
+		if err := stop(); err != nil {
+			log.Warning("cannot write profile: %s", err)
+		}
+	}
+	os.Exit(status)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval is the time between redraws of the progress shown by
+// --progress.
+const progressInterval = 200 * time.Millisecond
+
+// progressSize returns the size in bytes of the input ior reads, when the user
+// asks to see the progress of reading it, and both its size and a terminal to
+// show the progress on are known.
+func progressSize(ior io.Reader) (int64, bool) {
+	if !optProgress || !isTerminal(os.Stderr) {
+		return 0, false
+	}
+	var size int64
+	switch r := ior.(type) {
+	case *mappedFile:
+		size = r.Size()
+	case *os.File:
+		fi, err := r.Stat()
+		if err != nil || !fi.Mode().IsRegular() {
+			return 0, false
+		}
+		size = fi.Size()
+	}
+	return size, size > 0
+}
+
+// showProgress shows on standard error how many of the size bytes of an input
+// have been read, as counted by countLine, until the returned function is
+// called, which erases it.
+func showProgress(size int64) func() {
+	start := atomic.LoadInt64(&runStats.bytes)
+	done, stopped := make(chan struct{}), make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				io.WriteString(os.Stderr, "\r"+termClearLine)
+				return
+			case <-ticker.C:
+				read := atomic.LoadInt64(&runStats.bytes) - start
+				io.WriteString(os.Stderr, "\r"+progressBar(read, size, terminalWidth()))
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// progressBar returns a line no wider than width showing that read of size
+// bytes have been read.
+func progressBar(read, size int64, width int) string {
+	if read > size {
+		read = size // each line is counted with a newline, even the last
+	}
+	label := fmt.Sprintf(" %3d%% %6sB of %sB", read*100/size, humanize(read, humanizeIEC), humanize(size, humanizeIEC))
+	bar := width - len(label) - 3 // for the brackets, and the cursor
+	if bar < 10 {
+		return strings.TrimPrefix(label, " ")
+	}
+	filled := int(int64(bar) * read / size)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(" ", bar-filled) + "]" + label
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ragged policies determine what happens to rows whose number of fields
+// differs from the expected number of columns.
+const (
+	raggedPad       = "pad"        // silently pad short rows
+	raggedWarn      = "warn"       // log a warning for each ragged row
+	raggedError     = "error"      // stop at the first ragged row
+	raggedMergeLast = "merge-last" // merge surplus fields into the final column
+	raggedStrict    = "strict"     // report every ragged row, then fail
+)
+
+// expectedColumns returns the number of columns rows are expected to have,
+// which is the number of fields of the header row when there is one, and
+// otherwise the most common number of fields among the non-empty rows,
+// preferring the greater number in case of a tie.
+func expectedColumns(header []string, rows [][]string) int {
+	if len(header) > 0 {
+		return len(header)
+	}
+	counts := make(map[int]int)
+	var expected int
+	for _, fields := range rows {
+		if n := len(fields); n > 0 {
+			counts[n]++
+			if counts[n] > counts[expected] || (counts[n] == counts[expected] && n > expected) {
+				expected = n
+			}
+		}
+	}
+	return expected
+}
+
+// checkRagged applies the ragged policy to each non-empty row of rows whose
+// number of fields differs from n, where numbers holds the input line number
+// of each row, when known.
+func checkRagged(rows [][]string, numbers []int, n int, policy string) error {
+	var ragged int
+	for ri, fields := range rows {
+		if len(fields) == 0 || len(fields) == n {
+			continue
+		}
+		var number int
+		if ri < len(numbers) {
+			number = numbers[ri]
+		}
+		switch policy {
+		case raggedWarn:
+			log.Warning("line %d: found %d fields; expected %d", number, len(fields), n)
+		case raggedError:
+			return fmt.Errorf("line %d: found %d fields; expected %d", number, len(fields), n)
+		case raggedStrict:
+			log.Error("line %d: found %d fields; expected %d", number, len(fields), n)
+			ragged++
+		case raggedMergeLast:
+			if len(fields) > n && n > 0 {
+				fields[n-1] = strings.Join(fields[n-1:], " ")
+				rows[ri] = fields[:n]
+			}
+		}
+	}
+	if ragged > 0 {
+		return fmt.Errorf("found %d rows whose number of fields differs from %d", ragged, n)
+	}
+	return nil
+}
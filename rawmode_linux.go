@@ -0,0 +1,9 @@
+package main
+
+import "syscall"
+
+// Requests to get and set the terminal attributes.
+const (
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+)
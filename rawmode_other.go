@@ -0,0 +1,15 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// makeRaw returns an error, because terminals cannot be put into raw mode on
+// this platform.
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("cannot use interactive terminal on this platform")
+}
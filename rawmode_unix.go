@@ -0,0 +1,42 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// makeRaw puts the terminal f is connected to into raw mode, where each key
+// press is read as soon as it is typed, without being echoed, and returns a
+// function that restores the terminal to its prior mode.
+func makeRaw(f *os.File) (func(), error) {
+	var prior syscall.Termios
+	if err := termios(f, ioctlGetTermios, &prior); err != nil {
+		return nil, err
+	}
+
+	raw := prior
+	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := termios(f, ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+
+	return func() { _ = termios(f, ioctlSetTermios, &prior) }, nil
+}
+
+// termios gets or sets, depending on request, the attributes of the terminal
+// f is connected to.
+func termios(f *os.File, request uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), request, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// processBetween aligns each region of the lines read from ior which is
+// enclosed by a line matching the start pattern and a line matching the end
+// pattern, writing them to iow. All other lines, including the marker lines
+// themselves, and the lines of a region which is never closed, are written
+// unchanged. Each region is aligned as its own table.
+func processBetween(ior io.Reader, iow io.Writer) error {
+	headerLines := optHeaderLines // each region has its own header lines
+
+	br := newScanner(ior)
+	var region []string
+	var inside bool
+
+	for br.Scan() {
+		line := br.Text()
+		switch {
+		case !inside:
+			countLine(line) // lines of regions are counted as they are aligned
+			fmt.Fprintf(iow, "%s\n", line)
+			inside = optBetweenStart.MatchString(line)
+		case optBetweenEnd.MatchString(line):
+			optHeaderLines = headerLines
+			if err := process(strings.NewReader(strings.Join(region, "\n")), iow, ""); err != nil {
+				return err
+			}
+			countLine(line)
+			fmt.Fprintf(iow, "%s\n", line)
+			region = region[:0]
+			inside = false
+		default:
+			region = append(region, line)
+		}
+	}
+
+	for _, line := range region {
+		countLine(line)
+		fmt.Fprintf(iow, "%s\n", line)
+	}
+
+	return br.Err()
+}
+
+// regionConflicts returns the options the user provides which align the rows
+// of several files together, or compare two files, and so cannot be used when
+// regions of each file are aligned on their own.
+func regionConflicts() []string {
+	var names []string
+	for _, o := range []struct {
+		name string
+		used bool
+	}{
+		{"--combine", optCombine},
+		{"--with-filename", optWithFilename},
+		{"--delta", optDelta},
+	} {
+		if o.used {
+			names = append(names, o.name)
+		}
+	}
+	return names
+}
+
+// processSections aligns each section of the lines read from ior
+// independently, writing them to iow, where sections are separated by ruler
+// lines matching the section pattern. Each ruler is rendered anew to span the
+// width of the section preceding it, or of the section following it when no
+// section precedes it. When name is not empty, it is prepended to each row as
+// its own field.
+func processSections(ior io.Reader, iow io.Writer, name string) error {
+	br := newScanner(ior)
+	var section, rulers []string // lines of current section, and pending rulers
+	var width int                // width of previous section
+	var rendered bool            // whether any section has been rendered
+
+	// flush renders the current section, preceded by the pending rulers.
+	flush := func() error {
+		if len(section) == 0 {
+			return nil
+		}
+		var bb bytes.Buffer
+		if err := process(strings.NewReader(strings.Join(section, "\n")), &bb, name); err != nil {
+			return err
+		}
+		section = section[:0]
+		width = renderedWidth(bb.String())
+		rendered = true
+		for _, ruler := range rulers {
+			writeRuler(iow, ruler, width)
+		}
+		rulers = rulers[:0]
+		_, err := bb.WriteTo(iow)
+		return err
+	}
+
+	for br.Scan() {
+		line := br.Text()
+		if !optSectionRegex.MatchString(line) {
+			section = append(section, line)
+			continue
+		}
+		countLine(line) // lines of sections are counted as they are aligned
+		if err := flush(); err != nil {
+			return err
+		}
+		if rendered {
+			writeRuler(iow, line, width)
+		} else {
+			rulers = append(rulers, line)
+		}
+	}
+	if err := br.Err(); err != nil {
+		return err
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+	for _, ruler := range rulers {
+		// No section to span, so copy the rulers unchanged.
+		fmt.Fprintf(iow, "%s\n", ruler)
+	}
+	return nil
+}
+
+// sgrSequence matches a Select Graphic Rendition sequence.
+var sgrSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// renderedWidth returns the display width of the widest line of the rendered
+// text, not counting SGR sequences.
+func renderedWidth(text string) int {
+	var width int
+	for _, line := range strings.Split(sgrSequence.ReplaceAllString(text, ""), "\n") {
+		if w := displayWidth(line); w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// writeRuler writes the first character of ruler repeated to span width. A
+// ruler of only whitespace, such as a blank line, has no character to repeat,
+// so it is written as a blank line.
+func writeRuler(iow io.Writer, ruler string, width int) {
+	trimmed := strings.TrimSpace(ruler)
+	if trimmed == "" {
+		io.WriteString(iow, "\n")
+		return
+	}
+	r, _ := utf8.DecodeRuneInString(trimmed)
+	fmt.Fprintf(iow, "%s\n", strings.Repeat(string(r), width))
+}
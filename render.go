@@ -0,0 +1,555 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// layout is the rows of a table ready to be rendered, along with how some of
+// its rows and columns are to be treated.
+type layout struct {
+	lines      [][]string
+	heads      int            // number of header rows at the start of lines
+	unmeasured int            // number of rows at the start of lines not affecting widths
+	footers    int            // number of rows at the end of lines not affecting widths
+	signed     map[int]bool   // columns whose numbers are colored by their sign
+	percent    map[int]bool   // columns of percentages, treated like numbers
+	verbatim   map[int]string // rows rendered as is, without alignment
+	indent     string         // prefix of each rendered line
+	title      string         // title rendered above the table
+	minimums   []int          // widths columns are at least as wide as
+	numeric    map[int]bool   // numeric columns, when not determined from lines
+	suffixed   map[int]bool   // suffixed columns, when not determined from lines
+	uncounted  bool           // not counted by --stats, like panes of a table
+}
+
+// render writes the rows of l to iow, with each column padded to a common
+// width.
+func render(iow io.Writer, l layout) {
+	lines, unmeasured, footers := l.lines, l.unmeasured, len(l.lines)-l.footers
+	suffixed, numeric := l.suffixed, l.numeric
+	if suffixed == nil {
+		suffixed = suffixedColumns(lines[unmeasured:footers])
+	}
+	widths := columnWidths(lines[unmeasured:footers])
+	padSuffixed(widths, lines[unmeasured:footers], suffixed)
+	widths = growWidths(widths, len(l.minimums))
+	for i, width := range l.minimums {
+		if width > widths[i] {
+			widths[i] = width
+		}
+	}
+	if !l.uncounted {
+		countTable(footers-l.heads-len(l.verbatim), widths)
+	}
+	if numeric == nil {
+		numeric = numericColumns(lines[l.heads:footers]) // for justifying header rows
+		for i := range l.percent {
+			numeric[i] = true
+		}
+	}
+
+	// Columns narrower than their widest field either wrap, truncate, or
+	// overflow their wide fields.
+	policies := make(map[int]string, len(widths))
+	for i, width := range widths {
+		policies[i] = overflowTruncate
+		if max := optWrap.get(i); max > 0 && width > max {
+			widths[i] = max
+			policies[i] = overflowWrap
+		} else if max := optMaxWidth.get(i); max > 0 && width > max {
+			widths[i] = max
+		}
+		if min := optMinWidth.get(i); widths[i] < min {
+			widths[i] = min
+		}
+		if i < len(optColumnWidths) {
+			widths[i] = optColumnWidths[i]
+			policies[i] = optOverflow.get(i)
+		}
+	}
+
+	if optFit || optWrapFit {
+		width := int(optWidth)
+		if width == 0 {
+			width = terminalWidth()
+		}
+		fit(widths, policies, width)
+	}
+
+	if optReportWidths {
+		reportWidths(os.Stderr, lines[unmeasured:footers], numericColumns(lines[unmeasured:footers]), widths, policies)
+	}
+
+	if l.title != "" {
+		writeTitle(iow, l.indent, l.title, tableWidth(widths))
+	}
+
+	var cells [][]string
+	var single []string // backing of the single line cells of each row, reused
+	var rows int        // number of rows rendered, for striping
+	buf := getBuffer()  // each physical line, written at once
+	defer func() { putBuffer(buf) }()
+
+	for li, line := range lines {
+		if text, ok := l.verbatim[li]; ok {
+			io.WriteString(iow, text+"\n")
+			continue
+		}
+		if line == nil {
+			io.WriteString(iow, l.indent)
+			writeRule(iow, widths)
+			continue
+		}
+
+		// Each field may occupy several physical lines when wrapped, so
+		// determine all of the lines for each field before printing any.
+		cells = cells[:0]
+		single = append(single[:0], line...)
+		height := 1
+		for i, field := range line {
+			var cell []string
+			switch {
+			case li < unmeasured || li >= footers:
+				// Fields which do not affect column widths always overflow.
+				cell = single[i : i+1 : i+1]
+			case displayWidth(field) <= widths[i] || policies[i] == overflowOverflow:
+				cell = single[i : i+1 : i+1]
+			case policies[i] == overflowWrap:
+				cell = wrap(field, widths[i])
+			default:
+				single[i] = truncate(field, widths[i])
+				cell = single[i : i+1 : i+1]
+			}
+			if len(cell) > height {
+				height = len(cell)
+			}
+			cells = append(cells, cell)
+		}
+
+		// Alternate rows following the header rows have a background color,
+		// which spans the delimiters as well as the fields.
+		var stripe bool
+		if li >= l.heads {
+			stripe = useColor && optStripe && styles.stripe != "" && rows%2 == 1
+			rows++
+		}
+
+		for row := 0; row < height; row++ {
+			buf = append(buf[:0], l.indent...)
+			if stripe {
+				buf = append(buf, styles.stripe...)
+			}
+			for i := 0; i < len(line); i++ {
+				d := delimiter(i)
+				final := i == len(line)-1
+				// Print newline instead of delimiter for final column.
+				if final {
+					d = "\n"
+				}
+
+				var field string
+				if row < len(cells[i]) {
+					field = cells[i][row]
+				}
+				var width int // columns only of unmeasured rows have no width
+				if i < len(widths) {
+					width = widths[i]
+				}
+
+				// Header rows and missing values are justified like the rest
+				// of their columns.
+				var rightJustify bool
+				if li >= l.heads && !isMissing(line[i]) {
+					rightJustify = justifyRight(i, line[i])
+					// Only percentages of --delta, which are not otherwise
+					// numbers, are forced right.
+					if l.percent[i] && !rightJustify {
+						if _, ok := percentValue(line[i]); ok {
+							_, overridden := columnJustification(i)
+							rightJustify = !overridden
+						}
+					}
+				} else if right, ok := columnJustification(i); ok {
+					rightJustify = right
+				} else {
+					rightJustify = numeric[i]
+				}
+
+				// Omit padding after the field when tabs or nothing follow it.
+				trim := (final && optNoTrailingSpace) || (optOutputTabs > 0 && !rightJustify)
+
+				if optOutputTabs > 0 && !final {
+					written := width
+					if !rightJustify {
+						written = displayWidth(field)
+					}
+					d = strings.Repeat("\t", tabStops(width, written, int(optOutputTabs)))
+				}
+
+				// Style the padded field, but not the delimiter.
+				if style := cellStyle(l, li, i, field); style != "" {
+					buf = append(buf, style...)
+					if stripe && !final {
+						d = sgrReset + styles.stripe + d
+					} else {
+						d = sgrReset + d
+					}
+				} else if stripe && final {
+					d = sgrReset + d
+				}
+
+				if trim && (!rightJustify || field == "") {
+					buf = append(buf, field...)
+				} else if !rightJustify {
+					buf = left(buf, width, field)
+				} else if suffixed[i] && isNumeric(field) && !hasNegativeSuffix(field) {
+					// Leave room for the closing parenthesis or trailing minus
+					// sign of the negative numbers in this column, which
+					// padSuffixed widened for it.
+					buf = right(buf, width-1, field)
+					if !trim {
+						buf = append(buf, ' ')
+					}
+				} else {
+					buf = right(buf, width, field)
+				}
+				buf = append(buf, d...)
+			}
+			iow.Write(buf)
+		}
+	}
+}
+
+// cellStyle returns the SGR sequence for rendering field, found in the column
+// with index i of the row with index li, or the empty string when the field is
+// rendered without style.
+func cellStyle(l layout, li, i int, field string) string {
+	if li < l.heads {
+		return optHeaderStyle
+	}
+	if useColor {
+		for _, cr := range optColorRules {
+			if cr.matches(i, field) {
+				return cr.style
+			}
+		}
+	}
+	if useColor && (l.signed[i] || optColorSign) {
+		if v, ok := l.number(i, field); ok {
+			switch {
+			case v < 0:
+				return styles.negative
+			case v > 0 && (l.signed[i] || optColorPositive):
+				return styles.positive
+			}
+		}
+	}
+	if useColor && field != "" {
+		if _, ok := l.number(i, field); ok || isNumeric(field) {
+			return styles.number
+		}
+		return styles.text
+	}
+	return ""
+}
+
+// number returns the value of field, found in the column with index i, and
+// whether it is a number, which in a column of percentages includes a number
+// followed by a percent sign.
+func (l layout) number(i int, field string) (float64, bool) {
+	if l.percent[i] {
+		if f, ok := percentValue(field); ok {
+			return f, true
+		}
+	}
+	return parseNumber(field)
+}
+
+// writeRule writes a horizontal rule spanning each column of the table.
+func writeRule(iow io.Writer, widths []int) {
+	for i := 0; i < len(widths); i++ {
+		d := delimiter(i)
+		if i == len(widths)-1 {
+			d = "\n"
+		} else if optOutputTabs > 0 {
+			d = strings.Repeat("\t", tabStops(widths[i], widths[i], int(optOutputTabs)))
+		}
+		if useColor && styles.rule != "" {
+			fmt.Fprintf(iow, "%s%s%s%s", styles.rule, strings.Repeat("-", widths[i]), sgrReset, d)
+		} else {
+			fmt.Fprintf(iow, "%s%s", strings.Repeat("-", widths[i]), d)
+		}
+	}
+}
+
+// tableWidth returns the number of display columns spanned by a row of a
+// table whose columns have the specified widths.
+func tableWidth(widths []int) int {
+	var total int
+	for i := 0; i < len(widths); i++ {
+		total += widths[i]
+		if i < len(widths)-1 {
+			if optOutputTabs > 0 {
+				total = (total/int(optOutputTabs) + 1) * int(optOutputTabs)
+			} else {
+				total += displayWidth(delimiter(i))
+			}
+		}
+	}
+	return total
+}
+
+// writeTitle writes title, aligned over a table of the specified width, and
+// when requested, underlined.
+func writeTitle(iow io.Writer, indent, title string, width int) {
+	var pad int
+	if optTitleAlign == "center" && displayWidth(title) < width {
+		pad = (width - displayWidth(title)) / 2
+	}
+	fmt.Fprintf(iow, "%s%s%s\n", indent, strings.Repeat(" ", pad), title)
+	if optTitleUnderline {
+		fmt.Fprintf(iow, "%s%s%s\n", indent, strings.Repeat(" ", pad), strings.Repeat("=", displayWidth(title)))
+	}
+}
+
+// tabStops returns the number of tabs which advance from a position written
+// display columns into a column of the specified width, to the start of the
+// following column, when tab stops are every n display columns, and each
+// column starts at a tab stop and is followed by at least one tab.
+func tabStops(width, written, n int) int {
+	return width/n + 1 - written/n
+}
+
+// delimiters holds the delimiter following each column, the last of which also
+// follows each subsequent column.
+var delimiters = []string{" "}
+
+// parseDelimiters returns the delimiters listed in s, which is a comma
+// separated list of delimiters, such as " | , , | ", when it has more than one
+// item and none of them are empty. Otherwise s is the sole delimiter, so that
+// a delimiter such as "," or ", " may still be used.
+func parseDelimiters(s string) []string {
+	list := strings.Split(s, ",")
+	if len(list) < 2 {
+		return []string{s}
+	}
+	for _, item := range list {
+		if item == "" {
+			return []string{s}
+		}
+	}
+	return list
+}
+
+// delimiter returns the delimiter following the column with index i.
+func delimiter(i int) string {
+	if i < len(delimiters) {
+		return delimiters[i]
+	}
+	return delimiters[len(delimiters)-1]
+}
+
+// padDelimiter returns delimiter with spaces added, as necessary, so that at
+// least n spaces separate the fields on either side of it. A delimiter of
+// only spaces is lengthened to n spaces, while any other delimiter is given
+// at least n spaces on each of its sides.
+func padDelimiter(delimiter string, n int) string {
+	trimmed := strings.Trim(delimiter, " ")
+	if trimmed == "" {
+		if len(delimiter) < n {
+			return strings.Repeat(" ", n)
+		}
+		return delimiter
+	}
+	leading := strings.Index(delimiter, trimmed)
+	trailing := len(delimiter) - leading - len(trimmed)
+	if leading < n {
+		delimiter = strings.Repeat(" ", n-leading) + delimiter
+	}
+	if trailing < n {
+		delimiter += strings.Repeat(" ", n-trailing)
+	}
+	return delimiter
+}
+
+// fit shrinks the widest columns, as necessary, so that a row of the table is
+// no wider than the specified width. Fields wider than their shrunken columns
+// are truncated, unless their column's overflow policy is to wrap them, or
+// the user wraps fields to fit.
+func fit(widths []int, policies map[int]string, width int) {
+	// Space available for fields after accounting for delimiters.
+	available := width
+	for i := 0; i < len(widths)-1; i++ {
+		available -= displayWidth(delimiter(i))
+	}
+
+	total := func(limit int) int {
+		var sum int
+		for _, w := range widths {
+			if w > limit {
+				w = limit
+			}
+			sum += w
+		}
+		return sum
+	}
+
+	var limit int
+	for _, w := range widths {
+		if w > limit {
+			limit = w
+		}
+	}
+	if total(limit) <= available {
+		return // the table already fits
+	}
+	for limit > 1 && total(limit) > available {
+		limit--
+	}
+
+	for i, w := range widths {
+		if w > limit {
+			widths[i] = limit
+			if optWrapFit {
+				policies[i] = overflowWrap
+			} else if policies[i] != overflowWrap {
+				if policies[i] = optOverflow.get(i); policies[i] == overflowOverflow {
+					policies[i] = overflowTruncate
+				}
+			}
+		}
+	}
+}
+
+// columnWidths returns the width of the widest field in each column.
+func columnWidths(lines [][]string) []int {
+	widths := make([]int, 0, 16) // pre-allocate 16 columns
+	for _, fields := range lines {
+		widths = growWidths(widths, len(fields))
+		for i, field := range fields {
+			if width := displayWidth(field); width > widths[i] { // if width wider than previous width
+				widths[i] = width // save this width as new widest width for this column
+			}
+		}
+	}
+	return widths
+}
+
+// growWidths returns widths lengthened, as necessary, to hold the widths of n
+// columns, with each added column having no width.
+func growWidths(widths []int, n int) []int {
+	for len(widths) < n {
+		widths = append(widths, 0)
+	}
+	return widths
+}
+
+// displayWidth returns the number of terminal columns field occupies.
+func displayWidth(field string) int {
+	return utf8.RuneCountInString(field)
+}
+
+// ellipsis replaces the characters removed from a truncated field.
+const ellipsis = "…"
+
+// truncate returns field shortened to width display columns, with an ellipsis
+// in place of the characters removed. The user selects whether characters are
+// removed from the right, left, or middle of field.
+func truncate(field string, width int) string {
+	if width < 1 {
+		return ""
+	}
+	runes := []rune(field)
+	keep := width - 1 // leave room for the ellipsis
+	switch optTruncate {
+	case "left":
+		return ellipsis + string(runes[len(runes)-keep:])
+	case "middle":
+		head := (keep + 1) / 2
+		return string(runes[:head]) + ellipsis + string(runes[len(runes)-(keep-head):])
+	default:
+		return string(runes[:keep]) + ellipsis
+	}
+}
+
+// wrap returns the lines of field word wrapped to width display columns.
+// Words wider than width are broken across lines.
+func wrap(field string, width int) []string {
+	var lines []string
+	var line []rune
+
+	for _, word := range strings.Fields(field) {
+		runes := []rune(word)
+		if len(line) > 0 && len(line)+1+len(runes) > width {
+			lines = append(lines, string(line))
+			line = line[:0]
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		for len(line)+len(runes) > width {
+			n := width - len(line)
+			lines = append(lines, string(append(line, runes[:n]...)))
+			line, runes = line[:0], runes[n:]
+		}
+		line = append(line, runes...)
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, string(line))
+	}
+
+	return lines
+}
+
+// justifyRight returns true when field, found in the column with index i,
+// ought to be right justified.
+func justifyRight(i int, field string) bool {
+	if right, ok := columnJustification(i); ok {
+		return right
+	}
+	// Right justify if field is a number; otherwise left justify.
+	return isNumeric(field)
+}
+
+// columnJustification returns whether the column with index i ought to be
+// right justified, and whether command line options determine its
+// justification at all, rather than its fields.
+func columnJustification(i int) (bool, bool) {
+	switch {
+	case optLeftColumns.contains(i):
+		return false, true
+	case optRightColumns.contains(i):
+		return true, true
+	case optLeftJustify:
+		return false, true
+	case optRightJustify:
+		return true, true
+	case optTextColumns.contains(i):
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// fill appends to buf the padding that widens field to width display columns,
+// made of the padding character.
+func fill(buf []byte, width int, field string) []byte {
+	for n := width - displayWidth(field); n > 0; n-- {
+		buf = append(buf, optPadChar...)
+	}
+	return buf
+}
+
+// left appends to buf field left justified in width display columns.
+func left(buf []byte, width int, field string) []byte {
+	return fill(append(buf, field...), width, field)
+}
+
+// right appends to buf field right justified in width display columns.
+func right(buf []byte, width int, field string) []byte {
+	return append(fill(buf, width, field), field...)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// benchmarkRows is the number of rows of the table the benchmarks render,
+// enough that the cost of each field dominates.
+const benchmarkRows = 2000000
+
+var benchmarkTable struct {
+	once  sync.Once
+	lines [][]string
+}
+
+// benchmarkLines returns the rows of a table of names, numbers, and states,
+// like the output of ps, built once and shared by the benchmarks. Fields are
+// drawn from small sets of strings, so that the table fits in memory.
+func benchmarkLines() [][]string {
+	benchmarkTable.once.Do(func() {
+		const distinct = 1000
+		names := make([]string, distinct)
+		numbers := make([]string, distinct)
+		for i := range names {
+			names[i] = fmt.Sprintf("process-%d", i*7919%distinct)
+			numbers[i] = strconv.Itoa(i * i * 37)
+		}
+		states := []string{"running", "sleeping", "stopped", "zombie"}
+
+		lines := make([][]string, benchmarkRows)
+		fields := make([]string, 4*benchmarkRows)
+		for i := range lines {
+			row := fields[4*i : 4*i+4 : 4*i+4]
+			row[0] = names[i%distinct]
+			row[1] = numbers[(i/3)%distinct]
+			row[2] = states[i%len(states)]
+			row[3] = numbers[(i*13)%distinct]
+			lines[i] = row
+		}
+		benchmarkTable.lines = lines
+	})
+	return benchmarkTable.lines
+}
+
+// mapColumnWidths is columnWidths as it was, with widths held in a map, kept
+// to compare with.
+func mapColumnWidths(lines [][]string) map[int]int {
+	widths := make(map[int]int, 16) // pre-allocate 16 columns
+	for _, fields := range lines {
+		for i, field := range fields {
+			if width := displayWidth(field); width > widths[i] { // if width wider than previous width
+				widths[i] = width // save this width as new widest width for this column
+			}
+		}
+	}
+	return widths
+}
+
+func BenchmarkColumnWidths(b *testing.B) {
+	lines := benchmarkLines()
+
+	b.Run("map", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = mapColumnWidths(lines)
+		}
+	})
+
+	b.Run("slice", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = columnWidths(lines)
+		}
+	})
+}
+
+// fprintfLines writes lines to iow as render once did, with one call to
+// fmt.Fprintf for each field.
+func fprintfLines(iow io.Writer, lines [][]string, widths []int, numeric map[int]bool) {
+	for _, line := range lines {
+		for i, field := range line {
+			d := delimiter(i)
+			if i == len(line)-1 {
+				d = "\n"
+			}
+			if numeric[i] {
+				fmt.Fprintf(iow, "%*s%s", widths[i], field, d)
+			} else {
+				fmt.Fprintf(iow, "%-*s%s", widths[i], field, d)
+			}
+		}
+	}
+}
+
+// bufferLines writes lines to iow as render does, building each line in a
+// reused buffer, and writing it at once.
+func bufferLines(iow io.Writer, lines [][]string, widths []int, numeric map[int]bool) {
+	buf := getBuffer()
+	defer func() { putBuffer(buf) }()
+	for _, line := range lines {
+		buf = buf[:0]
+		for i, field := range line {
+			if numeric[i] {
+				buf = right(buf, widths[i], field)
+			} else {
+				buf = left(buf, widths[i], field)
+			}
+			if i < len(line)-1 {
+				buf = append(buf, delimiter(i)...)
+			}
+		}
+		buf = append(buf, '\n')
+		iow.Write(buf)
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	lines := benchmarkLines()
+	widths := columnWidths(lines)
+	numeric := numericColumns(lines)
+
+	b.Run("fprintf", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			fprintfLines(ioutil.Discard, lines, widths, numeric)
+		}
+	})
+
+	b.Run("buffer", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bufferLines(ioutil.Discard, lines, widths, numeric)
+		}
+	})
+
+	b.Run("render", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			render(ioutil.Discard, layout{lines: lines, uncounted: true})
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// reportWidths writes to iow one line for each column of the table whose
+// measured rows are lines, describing its inferred type and justification, the
+// narrowest and widest of its non-empty fields, and the width and overflow
+// policy chosen for it.
+func reportWidths(iow io.Writer, lines [][]string, numeric map[int]bool, widths []int, policies map[int]string) {
+	mins := make(map[int]int, len(widths))
+	maxs := make(map[int]int, len(widths))
+	for _, fields := range lines {
+		for i, field := range fields {
+			if field == "" {
+				continue
+			}
+			width := displayWidth(field)
+			if min, ok := mins[i]; !ok || width < min {
+				mins[i] = width
+			}
+			if width > maxs[i] {
+				maxs[i] = width
+			}
+		}
+	}
+
+	for i := 0; i < len(widths); i++ {
+		kind, justify := "text", "left"
+		if numeric[i] {
+			kind, justify = "numeric", "right"
+		}
+		if right, ok := columnJustification(i); ok && right {
+			justify = "right"
+		} else if ok {
+			justify = "left"
+		}
+		fmt.Fprintf(iow, "column %d: type=%s justify=%s min=%d max=%d width=%d policy=%s\n", i+1, kind, justify, mins[i], maxs[i], widths[i], policies[i])
+	}
+}
+
+// diagnose writes to iow a description of how the table would be formatted:
+// how lines were split into fields, how many lines were treated as header,
+// footer, and passthrough lines, and the number of fields and inferred type of
+// each column.
+func (t *table) diagnose(iow io.Writer) error {
+	splitting := "whitespace separated fields"
+	if t.positional {
+		splitting = "column extents"
+	}
+	if optMaxColumns > 0 {
+		splitting += fmt.Sprintf(", limited to %d columns", optMaxColumns)
+	}
+	fmt.Fprintf(iow, "splitting: %s\n", splitting)
+
+	headers := "copied"
+	if optFormatHeader {
+		headers = "aligned"
+	}
+	fmt.Fprintf(iow, "header lines: %d (%s)\n", t.headers, headers)
+	fmt.Fprintf(iow, "footer lines: %d\n", t.cb.Len())
+	if len(t.passthrough) > 0 {
+		fmt.Fprintf(iow, "passthrough lines: %d\n", len(t.passthrough))
+	}
+
+	var header []string
+	if len(t.block) > 0 {
+		header = t.block[len(t.block)-1]
+	}
+	expected := expectedColumns(header, t.lines)
+	var ragged int
+	for _, fields := range t.lines {
+		if len(fields) > 0 && len(fields) != expected {
+			ragged++
+		}
+	}
+	fmt.Fprintf(iow, "rows: %d (%d with other than %d fields)\n", len(t.lines), ragged, expected)
+
+	numeric := numericColumns(t.lines)
+	n := columnCount(t.lines)
+	fmt.Fprintf(iow, "columns: %d\n", n)
+	for i := 0; i < n; i++ {
+		kind := "text"
+		if numeric[i] {
+			kind = "numeric"
+		}
+		fmt.Fprintf(iow, "column %d: type=%s\n", i+1, kind)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// interrupted is closed when the user interrupts or terminates the program,
+// after which input is no longer read, and what was read is written.
+var interrupted = make(chan struct{})
+
+// caught is the signal which closed interrupted.
+var caught os.Signal
+
+// handleSignals arranges for the first interrupt or termination signal to
+// close interrupted, and for a second one to exit at once.
+func handleSignals() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		caught = <-signals
+		close(interrupted)
+		os.Exit(signalStatus(<-signals))
+	}()
+}
+
+// isInterrupted returns true once the user interrupts or terminates the
+// program.
+func isInterrupted() bool {
+	select {
+	case <-interrupted:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,30 @@
+//go:build plan9
+// +build plan9
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// signalStatus returns 1, because notes, which stand in for signals on this
+// platform, have no numbers from which to derive an exit status.
+func signalStatus(sig os.Signal) int {
+	return 1
+}
+
+// exitStatus returns the exit status with which the command that returned err
+// ended, or 1 when it did not exit on its own.
+func exitStatus(err *exec.ExitError) int {
+	if status := err.ExitCode(); status >= 0 {
+		return status
+	}
+	return 1
+}
+
+// isBrokenPipe returns false, because writing to a pipe whose reader has gone
+// away is not reported as a distinct error on this platform.
+func isBrokenPipe(err error) bool {
+	return false
+}
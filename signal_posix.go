@@ -0,0 +1,36 @@
+//go:build !plan9
+// +build !plan9
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// signalStatus returns the exit status of a program ended by sig, following
+// the convention of the shell.
+func signalStatus(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// exitStatus returns the exit status with which the command that returned err
+// ended, which for a command killed by a signal follows the convention of the
+// shell, rather than being -1.
+func exitStatus(err *exec.ExitError) int {
+	if ws, ok := err.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return signalStatus(ws.Signal())
+	}
+	return err.ExitCode()
+}
+
+// isBrokenPipe returns true when err is the result of writing to a pipe whose
+// reader has gone away, such as a pager the user quit, or 'head'.
+func isBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sortKey is a column to sort rows by, and the direction to sort them.
+type sortKey struct {
+	column     int
+	descending bool
+}
+
+// parseSortKeys returns the sort keys described by s, a comma separated list
+// of column numbers, each optionally followed by a colon and a direction,
+// such as "3:desc,1:asc".
+func parseSortKeys(s string) ([]sortKey, error) {
+	var keys []sortKey
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		var direction string
+		if i := strings.IndexByte(item, ':'); i >= 0 {
+			item, direction = item[:i], item[i+1:]
+		}
+		column, err := parseColumnNumber(item)
+		if err != nil {
+			return nil, err
+		}
+		key := sortKey{column: column}
+		switch direction {
+		case "", "asc":
+		case "desc":
+			key.descending = true
+		default:
+			return nil, fmt.Errorf("cannot parse sort direction: %q", direction)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// sortRows sorts rows by the values in the columns of the sort keys, in order
+// of precedence. When a column is numeric, as determined by isNumericColumn,
+// rows are ordered by the values read by columnValues, with rows lacking a
+// value in that column sorted after the others regardless of direction;
+// otherwise rows are ordered lexically. The sort is stable, so rows with equal
+// values keep their input order.
+func sortRows(rows [][]string, keys []sortKey) {
+	numeric := make([]bool, len(keys))
+	values := make([]func(string) (float64, bool), len(keys))
+	for ki, key := range keys {
+		values[ki] = columnValues(rows, key.column)
+		numeric[ki] = isNumericColumn(rows, key.column, values[ki])
+	}
+
+	field := func(row []string, column int) string {
+		if column < len(row) {
+			return row[column]
+		}
+		return ""
+	}
+
+	// compare returns a negative number when a sorts before b, a positive
+	// number when b sorts before a, and zero when they are equal.
+	compare := func(a, b []string) int {
+		for ki, key := range keys {
+			af, bf := field(a, key.column), field(b, key.column)
+			var c int
+			if numeric[ki] {
+				av, aok := values[ki](af)
+				bv, bok := values[ki](bf)
+				switch {
+				case aok && !bok:
+					return -1 // numbers before text regardless of direction
+				case !aok && bok:
+					return 1
+				case !aok && !bok:
+					c = strings.Compare(af, bf)
+				case av < bv:
+					c = -1
+				case av > bv:
+					c = 1
+				}
+			} else {
+				c = strings.Compare(af, bf)
+			}
+			if key.descending {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return compare(rows[i], rows[j]) < 0
+	})
+}
+
+// humanSuffixes maps the size suffixes of human readable numbers, such as
+// those printed by `du -h`, or by --humanize, to their multipliers.
+var humanSuffixes = map[byte]float64{
+	'k': 1e3, // the SI thousands of --humanize
+	'K': 1 << 10,
+	'M': 1 << 20,
+	'G': 1 << 30,
+	'T': 1 << 40,
+	'P': 1 << 50,
+	'E': 1 << 60,
+}
+
+// columnValues returns the function with which sortRows, summarize, and
+// groupRows read the values of the fields in the column with index i of rows.
+// Without --sort-human, only numbers have values. With it, the fields may
+// also be sizes, such as "4.0K", or durations, such as "150ms", whichever more
+// of the column's fields are, but never both, so that "5m" in a column of
+// durations is five minutes rather than five mebibytes.
+func columnValues(rows [][]string, i int) func(string) (float64, bool) {
+	if !optSortHuman {
+		return numberValue
+	}
+	var sizes, durations int
+	for _, fields := range rows {
+		if i < len(fields) {
+			if _, ok := parseSize(fields[i]); ok {
+				sizes++
+			}
+			if _, ok := parseDuration(fields[i]); ok {
+				durations++
+			}
+		}
+	}
+	if durations > sizes {
+		return parseDuration
+	}
+	return parseSize
+}
+
+// numberValue returns the value of field when it is a number.
+func numberValue(field string) (float64, bool) {
+	if !isNumeric(field) {
+		return 0, false
+	}
+	return parseNumber(field)
+}
+
+// parseSize returns the value in bytes of field when it is a number, optionally
+// followed by a size suffix, such as "4.0K", "512M", "1.2G", or "3Gi". Suffixes
+// are upper case, as printed by `du -h` and `ls -lh`, other than the "k" of
+// --humanize, so that a lower case "m" is never taken to mean mebibytes.
+func parseSize(field string) (float64, bool) {
+	if l := len(field); l > 2 && field[l-1] == 'i' && field[l-2] != 'k' && humanSuffixes[field[l-2]] > 0 {
+		field = field[:l-1] // IEC suffixes, such as Ki, are powers of 1024 too
+	}
+	if l := len(field); l > 1 {
+		if multiplier, ok := humanSuffixes[field[l-1]]; ok {
+			if f, err := strconv.ParseFloat(field[:l-1], 64); err == nil {
+				return f * multiplier, true
+			}
+			return 0, false
+		}
+	}
+	return numberValue(field)
+}
+
+// parseDuration returns the value in nanoseconds of field when it is a
+// duration, such as "150ms" or "1h30m".
+func parseDuration(field string) (float64, bool) {
+	d, err := time.ParseDuration(field)
+	if err != nil {
+		return 0, false
+	}
+	return float64(d), true
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// splitFields returns the fields of line, split around runs of whitespace.
+// When the user limits the number of columns to N, only the first N-1 runs of
+// whitespace split fields, and the remainder of line, with its internal
+// whitespace intact, is the final field.
+func splitFields(line string) []string {
+	return appendFields(nil, line)
+}
+
+// appendFields appends the fields of line, as split by splitFields, to
+// fields, growing it at most once. Each field is a substring of line rather
+// than a copy, so retaining both line and its fields retains the text once.
+func appendFields(fields []string, line string) []string {
+	max := int(optMaxColumns)
+	n := countFields(line)
+	if max > 0 && n > max {
+		n = max
+	}
+	// Lines without fields still have a non-nil slice of them, because a nil
+	// row is rendered as a rule.
+	if fields == nil || cap(fields)-len(fields) < n {
+		grown := make([]string, len(fields), len(fields)+n)
+		copy(grown, fields)
+		fields = grown
+	}
+
+	start := len(fields)
+	rest := strings.TrimLeftFunc(line, unicode.IsSpace)
+	for rest != "" {
+		i := strings.IndexFunc(rest, unicode.IsSpace)
+		if i < 0 || (max > 0 && len(fields)-start == max-1) {
+			fields = append(fields, strings.TrimRightFunc(rest, unicode.IsSpace))
+			break
+		}
+		fields = append(fields, rest[:i])
+		rest = strings.TrimLeftFunc(rest[i:], unicode.IsSpace)
+	}
+	return fields
+}
+
+// countFields returns the number of runs of non-whitespace in line.
+func countFields(line string) int {
+	var n int
+	var inField bool
+	for _, r := range line {
+		space := unicode.IsSpace(r)
+		if !space && !inField {
+			n++
+		}
+		inField = !space
+	}
+	return n
+}
+
+// prefixedFields returns the fields of line, preceded by name when it is not
+// empty, allocating room for name along with the fields rather than
+// prepending it afterward.
+func prefixedFields(name, line string) []string {
+	if name == "" {
+		return splitFields(line)
+	}
+	return appendFields(append(make([]string, 0, 1+countFields(line)), name), line)
+}
+
+// Parsers determine how lines are split into fields.
+const (
+	parserAuto       = "auto"       // choose by sampling the input
+	parserFields     = "fields"     // split around runs of whitespace
+	parserPositional = "positional" // split by column extents
+)
+
+// parserSample is the number of rows sampled to choose a parser.
+const parserSample = 20
+
+// choosePositional returns true when rows read as texts ought to be split by
+// column extents rather than around whitespace. Unless the user chose a
+// parser, the first rows are sampled. When they do not all have the same
+// number of whitespace separated fields, yet each field of every row falls
+// within its own extent, the table is presumed to be sparse, with empty
+// cells that only their position can identify. Text that is not aligned has
+// extents spanning several fields of a row, and is still split around
+// whitespace.
+func choosePositional(texts []string) bool {
+	switch optParser {
+	case parserFields:
+		return false
+	case parserPositional:
+		return true
+	}
+	if len(texts) > parserSample {
+		texts = texts[:parserSample]
+	}
+	counts := make([]int, len(texts))
+	var first int
+	var varies bool
+	for i, text := range texts {
+		if counts[i] = len(splitFields(text)); counts[i] == 0 {
+			continue // blank lines are neither sparse nor dense
+		}
+		if first == 0 {
+			first = counts[i]
+		} else if counts[i] != first {
+			varies = true
+		}
+	}
+	if !varies {
+		return false
+	}
+	extents := detectExtents(texts)
+	for i, text := range texts {
+		var n int
+		for _, cell := range splitExtents(text, extents) {
+			if cell != "" {
+				n++
+			}
+		}
+		if n != counts[i] {
+			return false
+		}
+	}
+	return true
+}
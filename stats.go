@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// runStats are the statistics of the run printed by --stats, the count of
+// bytes of which is also shown by --progress. The counts of files, lines, and
+// bytes are updated atomically, because lines are scanned concurrently with
+// tables being rendered.
+var runStats struct {
+	start time.Time
+	files int64 // number of files, commands, and archive members read
+	lines int64 // number of lines read
+	bytes int64 // number of bytes of the lines read, including their newlines
+
+	mu      sync.Mutex // guards the fields below
+	rows    int        // number of rows rendered, not including header rows
+	columns int        // number of columns of the widest table
+	widest  int        // width of the widest column
+	column  int        // index of the widest column
+}
+
+// countFile counts a file read for --stats.
+func countFile() {
+	if optStats {
+		atomic.AddInt64(&runStats.files, 1)
+	}
+}
+
+// countLine counts a line read for --stats and --progress.
+func countLine(text string) {
+	if optStats || optProgress {
+		atomic.AddInt64(&runStats.lines, 1)
+		atomic.AddInt64(&runStats.bytes, int64(len(text))+1)
+	}
+}
+
+// countTable counts the rows of a table rendered for --stats, along with the
+// widths of its columns.
+func countTable(rows int, widths []int) {
+	if !optStats {
+		return
+	}
+	runStats.mu.Lock()
+	defer runStats.mu.Unlock()
+	runStats.rows += rows
+	if len(widths) > runStats.columns {
+		runStats.columns = len(widths)
+	}
+	for i, width := range widths {
+		if width > runStats.widest {
+			runStats.widest, runStats.column = width, i
+		}
+	}
+}
+
+// writeStats writes the statistics of the run to iow.
+func writeStats(iow io.Writer) {
+	runStats.mu.Lock()
+	defer runStats.mu.Unlock()
+	fmt.Fprintf(iow, "files read:     %d\n", atomic.LoadInt64(&runStats.files))
+	fmt.Fprintf(iow, "lines read:     %d\n", atomic.LoadInt64(&runStats.lines))
+	fmt.Fprintf(iow, "bytes read:     %sB\n", humanize(atomic.LoadInt64(&runStats.bytes), humanizeIEC))
+	fmt.Fprintf(iow, "rows:           %d\n", runStats.rows)
+	fmt.Fprintf(iow, "columns:        %d\n", runStats.columns)
+	if runStats.columns > 0 {
+		fmt.Fprintf(iow, "widest column:  %d (%d characters)\n", runStats.column+1, runStats.widest)
+	}
+	fmt.Fprintf(iow, "elapsed time:   %s\n", time.Since(runStats.start).Round(time.Millisecond))
+	fmt.Fprintf(iow, "peak memory:    %sB\n", humanize(peakMemory(), humanizeIEC))
+}
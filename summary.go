@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// aggregates maps the names of the supported aggregates to functions that
+// compute them from a column's values.
+var aggregates = map[string]func([]float64) float64{
+	"avg": func(values []float64) float64 {
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	},
+	"count": func(values []float64) float64 {
+		return float64(len(values))
+	},
+	"max": func(values []float64) float64 {
+		max := math.Inf(-1)
+		for _, v := range values {
+			max = math.Max(max, v)
+		}
+		return max
+	},
+	"min": func(values []float64) float64 {
+		min := math.Inf(1)
+		for _, v := range values {
+			min = math.Min(min, v)
+		}
+		return min
+	},
+	"sum": func(values []float64) float64 {
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum
+	},
+}
+
+// parseAggregates returns the names in the comma separated list s, such as
+// "sum,avg,max", after verifying each is a supported aggregate.
+func parseAggregates(s string) ([]string, error) {
+	names := strings.Split(s, ",")
+	for i, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := aggregates[name]; !ok {
+			return nil, fmt.Errorf("cannot parse aggregate name: %q", name)
+		}
+		names[i] = name
+	}
+	return names, nil
+}
+
+// fractionDigits returns the number of digits after the decimal point of
+// field.
+func fractionDigits(field string) int {
+	i := strings.IndexByte(field, '.')
+	if i < 0 {
+		return 0
+	}
+	var n int
+	for _, c := range field[i+1:] {
+		if c < '0' || c > '9' {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// summarize returns the rows that summarize the numeric columns of rows, one
+// row for each of the named aggregates, preceded by a nil row, which renders
+// as a rule. A column is numeric when most of its non-empty fields are
+// numeric, so that a header row in rows does not prevent summarizing, and its
+// other fields are ignored. Text columns are left blank, except the first
+// column, which when it holds text, is labeled with the name of each
+// aggregate. Missing values count as zero, unless --na-skip is provided, in
+// which case they are ignored.
+func summarize(rows [][]string, names []string) [][]string {
+	n := columnCount(rows)
+	values := make([][]float64, n)
+	digits := make([]int, n)
+	texts := make([]int, n)
+	value := make([]func(string) (float64, bool), n)
+	for i := range value {
+		value[i] = columnValues(rows, i)
+	}
+
+	for _, fields := range rows {
+		for i, field := range fields {
+			if field == "" {
+				continue
+			}
+			if optNAValues[field] {
+				if !optNASkip {
+					values[i] = append(values[i], 0)
+				}
+				continue
+			}
+			v, ok := value[i](field)
+			if !ok {
+				texts[i]++
+				continue
+			}
+			values[i] = append(values[i], v)
+			if d := fractionDigits(field); d > digits[i] {
+				digits[i] = d
+			}
+		}
+	}
+
+	summary := [][]string{nil}
+
+	for _, name := range names {
+		row := make([]string, n)
+		for i := 0; i < n; i++ {
+			if len(values[i]) <= texts[i] {
+				continue
+			}
+			precision := digits[i]
+			switch name {
+			case "avg":
+				if precision < 2 {
+					precision = 2
+				}
+			case "count":
+				precision = 0
+			}
+			row[i] = strconv.FormatFloat(aggregates[name](values[i]), 'f', precision, 64)
+		}
+		if n > 0 && row[0] == "" {
+			row[0] = name
+		}
+		summary = append(summary, row)
+	}
+
+	return summary
+}
+
+// aggregateSpec is a request to aggregate the values of a column, such as
+// "sum(3)". The count aggregate needs no column, and its column index is -1.
+type aggregateSpec struct {
+	name   string
+	column int
+	label  string // the specification as the user wrote it
+}
+
+// parseAggregateSpecs returns the aggregate specifications in the comma
+// separated list s, such as "sum(3),count,avg(5)".
+func parseAggregateSpecs(s string) ([]aggregateSpec, error) {
+	var specs []aggregateSpec
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		spec := aggregateSpec{name: strings.ToLower(item), column: -1, label: item}
+		if i := strings.IndexByte(item, '('); i >= 0 {
+			if !strings.HasSuffix(item, ")") {
+				return nil, fmt.Errorf("cannot parse aggregate: %q", item)
+			}
+			column, err := parseColumnNumber(item[i+1 : len(item)-1])
+			if err != nil {
+				return nil, err
+			}
+			spec.name, spec.column = strings.ToLower(item[:i]), column
+		}
+		if _, ok := aggregates[spec.name]; !ok {
+			return nil, fmt.Errorf("cannot parse aggregate name: %q", spec.name)
+		}
+		if spec.column < 0 && spec.name != "count" {
+			return nil, fmt.Errorf("cannot use aggregate without a column: %q", item)
+		}
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
+
+// groupRows collapses rows which share the same value in the key column into
+// a single row, in order of each key's first appearance. Each collapsed row
+// holds the key, followed by the requested aggregates of its group. Fields
+// which are not numeric are ignored by all aggregates except count, which
+// counts rows.
+func groupRows(rows [][]string, key int, specs []aggregateSpec) [][]string {
+	type group struct {
+		key    string
+		rows   int
+		values [][]float64 // values for each spec
+		digits []int       // fraction digits for each spec
+	}
+
+	var order []*group
+	groups := make(map[string]*group)
+	value := make([]func(string) (float64, bool), len(specs))
+	for si, spec := range specs {
+		value[si] = columnValues(rows, spec.column)
+	}
+
+	for _, fields := range rows {
+		var k string
+		if key < len(fields) {
+			k = fields[key]
+		}
+		g, ok := groups[k]
+		if !ok {
+			g = &group{key: k, values: make([][]float64, len(specs)), digits: make([]int, len(specs))}
+			groups[k] = g
+			order = append(order, g)
+		}
+		g.rows++
+		for si, spec := range specs {
+			if spec.column < 0 || spec.column >= len(fields) {
+				continue
+			}
+			field := fields[spec.column]
+			if v, ok := value[si](field); ok {
+				g.values[si] = append(g.values[si], v)
+				if d := fractionDigits(field); d > g.digits[si] {
+					g.digits[si] = d
+				}
+			}
+		}
+	}
+
+	grouped := make([][]string, 0, len(order))
+	for _, g := range order {
+		row := []string{g.key}
+		for si, spec := range specs {
+			if spec.column < 0 {
+				row = append(row, strconv.Itoa(g.rows))
+				continue
+			}
+			if len(g.values[si]) == 0 {
+				row = append(row, "")
+				continue
+			}
+			precision := g.digits[si]
+			switch spec.name {
+			case "avg":
+				if precision < 2 {
+					precision = 2
+				}
+			case "count":
+				precision = 0
+			}
+			row = append(row, strconv.FormatFloat(aggregates[spec.name](g.values[si]), 'f', precision, 64))
+		}
+		grouped = append(grouped, row)
+	}
+
+	return grouped
+}
+
+// groupHeader returns the header row for grouped rows, given a header row of
+// the original rows.
+func groupHeader(header []string, key int, specs []aggregateSpec) []string {
+	row := make([]string, 0, 1+len(specs))
+	if key < len(header) {
+		row = append(row, header[key])
+	} else {
+		row = append(row, "")
+	}
+	for _, spec := range specs {
+		row = append(row, spec.label)
+	}
+	return row
+}
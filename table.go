@@ -0,0 +1,608 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/karrick/columnize/pkg/ringbuf"
+)
+
+// table accumulates the lines of one or more inputs, which are aligned
+// together once all input has been read.
+type table struct {
+	cb    *ringbuf.Buffer[sourceLine] // footer lines, which are not aligned
+	block [][]string                  // header lines aligned with, but not affecting, the table
+	lines [][]string                  // rows of the table
+
+	signed      map[int]bool      // columns whose numbers are colored by their sign
+	percent     map[int]bool      // columns of percentages, treated like numbers
+	trailer     []string          // lines following the table, which are not aligned
+	passthrough []passthroughLine // lines among the rows, which are not aligned
+	numbers     []int             // input line number of each row of the table
+	indents     []string          // leading whitespace of each row of the table
+	headers     int               // number of header lines read
+	texts       []string          // original text of each row of the table
+	blockTexts  []string          // original text of each aligned header line
+	positional  bool              // whether rows were split by column extents
+	cells       interner          // distinct fields of rows, when interning
+	first       int               // index of the first row of the input being read
+	interning   bool              // whether fields of the input being read are interned
+}
+
+// sourceLine is a line of input along with the name of the file it was read
+// from, when that name is needed.
+type sourceLine struct {
+	name, text string
+	fields     []string // name, when not empty, followed by the fields of text
+	number     int      // line number within its file
+}
+
+// Lines are scanned and split in batches of readBatch lines, and at most
+// readBacklog batches are split ahead of those being added to the table.
+const (
+	readBatch   = 256
+	readBacklog = 4
+)
+
+// lineScan is the state shared between the goroutine scanning lines for
+// read, and read itself.
+type lineScan struct {
+	batches chan []sourceLine // full batches, and the last one
+	mu      sync.Mutex        // held while adding to or sending a batch
+	batch   []sourceLine      // lines not yet sent
+	err     error             // error of reading, once batches is closed
+}
+
+// scan reads lines from ior, splitting each into fields preceded by name, and
+// sends them in batches on the batches channel, which is closed after the
+// last line, or after an error. Reading stalls while the batches already sent
+// have not been received.
+func scan(ior io.Reader, name string) *lineScan {
+	ls := &lineScan{batches: make(chan []sourceLine, readBacklog), batch: getBatch()}
+	go func() {
+		add := func(text string) {
+			countLine(text)
+			// The fields are substrings of the text, which is allocated once.
+			line := sourceLine{name: name, text: text, fields: prefixedFields(name, text)}
+			ls.mu.Lock()
+			if ls.batch = append(ls.batch, line); len(ls.batch) == readBatch {
+				ls.batches <- ls.batch
+				ls.batch = getBatch()
+			}
+			ls.mu.Unlock()
+		}
+		var err error
+		if mf, ok := ior.(*mappedFile); ok && optMaxLineBytes == 0 {
+			// Lines are copied straight out of the mapping, unless their
+			// length is limited, which the scanner of lines enforces.
+			mf.eachLine(func(line []byte) { add(string(line)) })
+		} else {
+			br := newScanner(ior)
+			for br.Scan() {
+				add(br.Text())
+			}
+			err = br.Err()
+		}
+		ls.mu.Lock()
+		if len(ls.batch) > 0 {
+			ls.batches <- ls.batch
+		}
+		ls.batch, ls.err = nil, err
+		close(ls.batches)
+		ls.mu.Unlock()
+	}()
+	return ls
+}
+
+// rest returns the lines scanned but not yet received, once the user
+// interrupts the program, after which the scanner, which may be blocked
+// reading, is abandoned.
+func (ls *lineScan) rest() []sourceLine {
+	var lines []sourceLine
+	for {
+		select {
+		case batch, ok := <-ls.batches:
+			if !ok {
+				return lines
+			}
+			lines = append(lines, batch...)
+			continue
+		default:
+		}
+		// The scanner holds the lock while sending, so once it is taken,
+		// every batch sent was received above, or is waiting to be.
+		if ls.mu.TryLock() {
+			break
+		}
+		runtime.Gosched()
+	}
+	defer ls.mu.Unlock()
+	for {
+		select {
+		case batch, ok := <-ls.batches:
+			if ok {
+				lines = append(lines, batch...)
+				continue
+			}
+		default:
+		}
+		lines = append(lines, ls.batch...)
+		ls.batch = nil
+		return lines
+	}
+}
+
+// passthroughLine is a line of input which is not aligned, along with the
+// number of rows of the table which preceded it.
+type passthroughLine struct {
+	rows int
+	text string
+}
+
+// newTable returns a new table, ready to read input.
+func newTable() (*table, error) {
+	// Use a cirular buffer, so we are processing the Nth previous line.
+	return &table{cb: ringbuf.New[sourceLine](int(optFooterLines))}, nil
+}
+
+// read appends the lines read from ior to the table. Header lines which are
+// not aligned are written directly to iow. When name is not empty, it is
+// prepended to each row of the table as its own field.
+func (t *table) read(ior io.Reader, iow io.Writer, name string) error {
+	var number int
+	blocks, rows := len(t.block), len(t.lines)
+	t.first, t.interning = rows, false
+	if size, ok := progressSize(ior); ok {
+		defer showProgress(size)()
+	}
+
+	// Lines are scanned and split concurrently with being added to the
+	// table, so reading a large input overlaps with processing it. When the
+	// user interrupts the program, the lines scanned so far are kept.
+	ls := scan(ior, name)
+	for {
+		var batch []sourceLine
+		var ok bool
+		select {
+		case batch, ok = <-ls.batches:
+		case <-interrupted:
+			batch = ls.rest()
+		}
+		for _, line := range batch {
+			number++
+			line.number = number
+			t.add(iow, line)
+		}
+		if !ok {
+			break
+		}
+		putBatch(batch)
+	}
+	if !isInterrupted() && ls.err != nil {
+		return ls.err
+	}
+
+	if choosePositional(t.texts[rows:]) {
+		t.positional = true
+		t.splitPositional(blocks, rows)
+	}
+	return nil
+}
+
+// add adds a line read by read to the table, unless it is a header line that
+// is not aligned, which is written directly to iow.
+func (t *table) add(iow io.Writer, line sourceLine) {
+	if optHeaderLines > 0 {
+		// Only need to count lines while ignoring headers.
+		if optFormatHeader {
+			t.block = append(t.block, line.fields)
+			t.blockTexts = append(t.blockTexts, line.text)
+		} else if optHeaderStyle != "" {
+			fmt.Fprintf(iow, "%s%s%s\n", optHeaderStyle, line.text, sgrReset)
+		} else {
+			fmt.Fprintf(iow, "%s\n", line.text)
+		}
+		optHeaderLines--
+		t.headers++
+		return
+	}
+
+	line, ok := t.cb.QueueDequeue(line)
+	if !ok {
+		// NOTE: A circular buffer always gives us Nth previous line. So
+		// this fills up the circular queue with N items, which we will
+		// process after the queue fills.
+		return
+	}
+
+	if optPreset == presetGobench && !strings.HasPrefix(line.text, "Benchmark") {
+		// The preamble and trailer surrounding benchmark results pass
+		// through without being aligned.
+		if len(t.lines) == 0 {
+			fmt.Fprintf(iow, "%s\n", line.text)
+		} else {
+			t.trailer = append(t.trailer, line.text)
+		}
+		return
+	}
+
+	if unaligned(line.text) {
+		t.passthrough = append(t.passthrough, passthroughLine{rows: len(t.lines), text: line.text})
+		return
+	}
+
+	if optDecimal {
+		var prefix int // the name is not a number
+		if line.name != "" {
+			prefix = 1
+		}
+		for i := prefix; i < len(line.fields); i++ {
+			line.fields[i] = normalizeBase(line.fields[i])
+		}
+	}
+
+	// Once the rows sampled to choose a parser show the input is split around
+	// whitespace, the text of each further line is no longer needed, so its
+	// fields may be interned and the line itself discarded.
+	if optIntern && !t.interning && !optDiagnose && !optShowExtents && len(t.lines)-t.first == parserSample {
+		t.interning = !choosePositional(t.texts[t.first:])
+		if t.interning && t.cells == nil {
+			t.cells = make(interner)
+		}
+	}
+	text := line.text
+	if t.interning {
+		for i, field := range line.fields {
+			line.fields[i] = t.cells.intern(field)
+		}
+		text = ""
+	}
+
+	t.lines = append(t.lines, line.fields)
+	if optRagged != raggedPad {
+		t.numbers = append(t.numbers, line.number) // only reported by checkRagged
+	}
+	t.texts = append(t.texts, text)
+	if optKeepIndent {
+		indent := line.text[:len(line.text)-len(strings.TrimLeft(line.text, " \t"))]
+		if t.interning {
+			indent = t.cells.intern(indent)
+		}
+		t.indents = append(t.indents, indent)
+	}
+}
+
+// write aligns and writes the table to iow, followed by its footer lines.
+func (t *table) write(iow io.Writer) error {
+	lines := t.lines
+	heads := len(t.block) // number of header rows at the start of lines
+
+	var indent string // common indentation of the rows
+	if optKeepIndent {
+		indent = t.indent()
+	}
+
+	if optRagged != raggedPad {
+		var header []string
+		if heads > 0 {
+			header = t.block[heads-1]
+		}
+		if err := checkRagged(lines, t.numbers, expectedColumns(header, lines), optRagged); err != nil {
+			return err
+		}
+	}
+
+	if optPreset == presetGobench {
+		var skip int
+		if optWithFilename {
+			skip = 1
+		}
+		gobenchColumns(lines, skip)
+		if optBenchstat {
+			lines = benchstatRows(lines, skip)
+		}
+	}
+
+	if optAttachUnits {
+		for i, fields := range lines {
+			lines[i] = attachUnits(fields)
+		}
+	}
+
+	if optAddHeader != nil {
+		// Copy the synthetic header, because fields may be rewritten in place.
+		header := append([]string(nil), optAddHeader...)
+		if optWithFilename {
+			header = append([]string{""}, header...)
+		}
+		lines = append([][]string{header}, lines...)
+		heads++
+	}
+
+	// The header block, the synthetic header, and the body are transformed
+	// together, so their columns remain in agreement. Only the header block
+	// does not affect column widths. Without a header block, the rows are
+	// transformed in place, rather than copied, since the table is written
+	// only once.
+	if len(t.block) > 0 {
+		lines = append(t.block, lines...)
+	}
+
+	// The columns kept by each projection, in order, which formatted footer
+	// rows keep as well.
+	var projections [][]int
+	project := func(indexes []int) {
+		projectColumns(lines, indexes)
+		projections = append(projections, indexes)
+	}
+
+	if optFields != nil {
+		project(optFields.indexes(columnCount(lines)))
+	}
+
+	if optSelect != nil && len(lines) > 0 {
+		indexes, err := headerIndexes(lines[0], optSelect)
+		if err != nil {
+			return err
+		}
+		project(indexes)
+	}
+
+	if (optDrop != nil || optDropMatching != nil) && len(lines) > 0 {
+		project(keptIndexes(columnCount(lines), lines[0], optDrop, optDropMatching))
+	}
+
+	if optMask != nil || optMaskByHeader != nil {
+		indexes := optMask.indexes(columnCount(lines))
+		if optMaskByHeader != nil && len(lines) > 0 {
+			named, err := headerIndexes(lines[0], optMaskByHeader)
+			if err != nil {
+				return err
+			}
+			indexes = append(indexes, named...)
+		}
+		maskColumns(lines[heads:], indexes)
+	}
+
+	if optAnonymize != nil {
+		var header []string
+		if heads > 0 {
+			header = lines[heads-1]
+		}
+		anonymizeColumns(lines[heads:], optAnonymize.indexes(columnCount(lines)), header)
+	}
+
+	if optNARep != "" {
+		for _, fields := range lines[heads:] {
+			for i, field := range fields {
+				if optNAValues[field] {
+					fields[i] = optNARep
+				}
+			}
+		}
+	}
+
+	if optCompute != nil {
+		// Pad short rows so computed columns line up.
+		n := columnCount(lines)
+		for li, fields := range lines {
+			for len(fields) < n {
+				fields = append(fields, "")
+			}
+			for _, c := range optCompute {
+				if li < heads {
+					fields = append(fields, c.name)
+				} else {
+					fields = append(fields, formatValue(c.expr(fields)))
+				}
+			}
+			lines[li] = fields
+		}
+	}
+
+	if optWhere != nil {
+		// Filter the rows following the header rows in place.
+		kept := lines[:heads]
+		for _, fields := range lines[heads:] {
+			if optWhere(fields).truthy() {
+				kept = append(kept, fields)
+			}
+		}
+		lines = kept
+	}
+
+	if optUnique || optUniqueBy > 0 {
+		column := int(optUniqueBy) - 1 // -1 compares entire rows
+		lines = append(lines[:heads], uniqueRows(lines[heads:], column)...)
+	}
+
+	if optGroupBy > 0 {
+		specs := optAggregate
+		if specs == nil {
+			specs = []aggregateSpec{{name: "count", column: -1, label: "count"}}
+		}
+		key := int(optGroupBy) - 1
+		for i := 0; i < heads; i++ {
+			lines[i] = groupHeader(lines[i], key, specs)
+		}
+		lines = append(lines[:heads], groupRows(lines[heads:], key, specs)...)
+	}
+
+	if optSort != nil {
+		// Only sort the rows following the header rows.
+		sortRows(lines[heads:], optSort)
+	}
+
+	if optTake > 0 && uint64(len(lines)-heads) > optTake {
+		lines = lines[:heads+int(optTake)]
+	}
+
+	if optTakeLast > 0 && uint64(len(lines)-heads) > optTakeLast {
+		lines = append(lines[:heads], lines[len(lines)-int(optTakeLast):]...)
+	}
+
+	if optAlignExponents {
+		alignExponents(lines)
+	}
+
+	body := len(lines) // end of the rows, before any summary rows
+
+	if optSummary != nil {
+		lines = append(lines, summarize(lines[heads:], optSummary)...)
+	}
+
+	if optHumanize {
+		humanizeNumbers(lines[heads:], numericColumns(lines[heads:body]))
+	}
+
+	if optPrecision >= 0 {
+		roundNumbers(lines[heads:], numericColumns(lines[heads:body]))
+	}
+
+	if optGroupDigits {
+		groupNumbers(lines[heads:], numericColumns(lines[heads:body]))
+	}
+
+	if optNegativeStyle != "" {
+		for _, fields := range lines {
+			for i, field := range fields {
+				fields[i] = restyleNegative(field, optNegativeStyle)
+			}
+		}
+	}
+
+	if optEmpty != "" {
+		fillEmpty(lines[heads:body], columnCount(lines[heads:body]), optEmpty)
+	}
+
+	verbatim := make(map[int]string) // rows rendered without alignment
+
+	if optGroupSeparator > 0 {
+		n := len(lines)
+		lines = separateGroups(lines, heads, body, int(optGroupSeparator)-1, !optGroupRule, verbatim)
+		body += len(lines) - n
+	}
+
+	if t.passthrough != nil {
+		lines, verbatim = insertPassthrough(lines, heads, body, verbatim, t.passthrough)
+	}
+
+	// The footer lines remaining in the circular buffer either align with the
+	// table, align as their own table, or are dumped as is.
+	var footer [][]string
+	if optFormatFooter != "" {
+		for _, line := range t.cb.Drain() {
+			fields := splitFields(line.text)
+			if len(fields) > 0 && line.name != "" && optFormatFooter == footerTable {
+				fields = append([]string{line.name}, fields...)
+			}
+			footer = append(footer, fields)
+		}
+		for _, indexes := range projections {
+			if optFormatFooter == footerSeparate && optWithFilename {
+				// Footer rows formatted on their own lack the file name.
+				indexes = withoutFirstColumn(indexes)
+			}
+			projectColumns(footer, indexes)
+		}
+	}
+
+	l := layout{lines: lines, heads: heads, unmeasured: len(t.block), signed: t.signed, percent: t.percent, verbatim: verbatim, indent: indent, title: optTitle}
+	if optFormatFooter == footerTable {
+		l.lines = appendFooter(l.lines, l.verbatim, footer)
+		l.footers = len(footer)
+	}
+
+	// All input has been read (and header has even been printed). Pretty print
+	// all lines collected thus far, remembering that there may be N lines left
+	// in the circular buffer remaining to be processed.
+	if optPaginateColumns {
+		paginate(iow, l)
+	} else {
+		render(iow, l)
+	}
+
+	for _, line := range t.trailer {
+		fmt.Fprintf(iow, "%s\n", line)
+	}
+
+	switch optFormatFooter {
+	case footerTable:
+		// Already rendered with the table.
+	case footerSeparate:
+		verbatim := make(map[int]string)
+		render(iow, layout{lines: appendFooter(nil, verbatim, footer), verbatim: verbatim})
+	default:
+		// Dump remaining contents of circular buffer.
+		for _, line := range t.cb.Drain() {
+			fmt.Fprintf(iow, "%s\n", line.text)
+		}
+	}
+
+	return nil
+}
+
+// withoutFirstColumn returns indexes of columns, less the first column, with
+// each index of the following columns one less.
+func withoutFirstColumn(indexes []int) []int {
+	shifted := make([]int, 0, len(indexes))
+	for _, i := range indexes {
+		if i > 0 {
+			shifted = append(shifted, i-1)
+		}
+	}
+	return shifted
+}
+
+// indent returns the indentation common to the non-empty rows of the table,
+// which is the shortest of their indentations. When --keep-nested-indent is
+// provided, the remainder of the indentation of each row is prepended to its
+// first field, so it is counted as part of the first column.
+func (t *table) indent() string {
+	if len(t.indents) != len(t.lines) {
+		return "" // rows were not read with their indentation
+	}
+
+	var common string
+	var found bool
+	for ri, fields := range t.lines {
+		if len(fields) > 0 && (!found || len(t.indents[ri]) < len(common)) {
+			common, found = t.indents[ri], true
+		}
+	}
+
+	if optKeepNestedIndent {
+		var first int
+		if optWithFilename {
+			first = 1 // skip the file name field
+		}
+		for ri, fields := range t.lines {
+			if first < len(fields) && len(t.indents[ri]) > len(common) {
+				fields[first] = t.indents[ri][len(common):] + fields[first]
+			}
+		}
+	}
+
+	return common
+}
+
+// Footer formats determine how the footer lines are aligned.
+const (
+	footerTable    = "table"    // aligned using the column widths of the table
+	footerSeparate = "separate" // aligned using their own column widths
+)
+
+// appendFooter returns lines with the rows of footer appended, adding those
+// without fields to verbatim, so they render as blank lines.
+func appendFooter(lines [][]string, verbatim map[int]string, footer [][]string) [][]string {
+	for _, fields := range footer {
+		if len(fields) == 0 {
+			verbatim[len(lines)] = ""
+		}
+		lines = append(lines, fields)
+	}
+	return lines
+}
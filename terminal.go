@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"strconv"
+)
+
+// defaultTerminalWidth is the width presumed when the terminal width cannot be
+// determined.
+const defaultTerminalWidth = 80
+
+// terminalWidth returns the number of columns of the user's terminal, as
+// reported by the kernel for standard output or standard error, whichever is
+// a terminal, or as advertised by the COLUMNS environment variable.
+func terminalWidth() int {
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		if columns, _ := windowSize(f); columns > 0 {
+			return columns
+		}
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return defaultTerminalWidth
+}
+
+// isTerminal returns true when f is a terminal, rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,12 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+import "os"
+
+// windowSize returns zeros, because the size of terminals cannot be queried
+// on this platform.
+func windowSize(f *os.File) (int, int) {
+	return 0, 0
+}
@@ -0,0 +1,26 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// winsize is the terminal window size reported by the TIOCGWINSZ ioctl.
+type winsize struct {
+	rows, columns, xpixels, ypixels uint16
+}
+
+// windowSize returns the number of columns and rows of the terminal f is
+// connected to, as reported by the kernel, or zeros when f is not a terminal.
+func windowSize(f *os.File) (int, int) {
+	var ws winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0, 0
+	}
+	return int(ws.columns), int(ws.rows)
+}
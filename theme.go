@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// theme holds the SGR sequences used to style the various parts of a table,
+// any of which may be empty for no style.
+type theme struct {
+	header   string // header rows, unless --header-style is provided
+	rule     string // horizontal rules
+	stripe   string // alternate rows, with --stripe
+	negative string // negative numbers, with --color-sign
+	positive string // positive numbers, with --color-positive
+	number   string // other numeric fields
+	text     string // other non-empty fields
+}
+
+// styles is the theme in effect, which defaults to the built in styles.
+var styles = theme{stripe: sgrStripe, negative: sgrRed, positive: sgrGreen}
+
+// themeExtension is the file name extension of theme files.
+const themeExtension = ".toml"
+
+// themePath returns the path of the theme file for name, which is either the
+// path of a theme file, or the name of a theme file without its extension in
+// the columnize themes directory of the user's configuration directory, such
+// as $HOME/.config/columnize/themes on Linux.
+func themePath(name string) (string, error) {
+	if strings.ContainsRune(name, filepath.Separator) || strings.HasSuffix(name, themeExtension) {
+		return name, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "columnize", "themes", name+themeExtension), nil
+}
+
+// loadTheme returns the theme named name, starting from the built in styles,
+// and replacing those specified by the theme file. A theme file is a small
+// subset of TOML, with one style per line, such as:
+//
+//	# Comments and blank lines are ignored.
+//	header = "bold,underline"
+//	rule = "dim"
+//	stripe = "bg-blue"
+//	negative = "bold,red"
+//	positive = ""
+//
+// where each key is one of header, rule, stripe, negative, positive, number,
+// or text, and each value is a style as accepted by --header-style, or the
+// empty string for no style.
+func loadTheme(name string) (theme, error) {
+	t := styles
+
+	path, err := themePath(name)
+	if err != nil {
+		return t, err
+	}
+
+	fh, err := os.Open(path)
+	if err != nil {
+		return t, err
+	}
+	defer fh.Close()
+
+	br := bufio.NewScanner(fh)
+	for ln := 1; br.Scan(); ln++ {
+		line := strings.TrimSpace(br.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		i := strings.IndexByte(line, '=')
+		if i < 0 {
+			return t, fmt.Errorf("cannot parse theme %q line %d without equal sign: %q", path, ln, line)
+		}
+		key := strings.TrimSpace(line[:i])
+		value := strings.TrimSpace(line[i+1:])
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		var style string
+		if value != "" {
+			if style, err = parseStyle(value); err != nil {
+				return t, fmt.Errorf("cannot parse theme %q line %d: %s", path, ln, err)
+			}
+		}
+
+		switch key {
+		case "header":
+			t.header = style
+		case "rule":
+			t.rule = style
+		case "stripe":
+			t.stripe = style
+		case "negative":
+			t.negative = style
+		case "positive":
+			t.positive = style
+		case "number":
+			t.number = style
+		case "text":
+			t.text = style
+		default:
+			return t, fmt.Errorf("cannot parse theme %q line %d with unknown key: %q", path, ln, key)
+		}
+	}
+
+	return t, br.Err()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// cancelGrace is how long work is given to return after its context is
+// canceled, before it is abandoned.
+const cancelGrace = 250 * time.Millisecond
+
+// timeoutContext returns a context which is canceled once the timeout the
+// user provides elapses, if they provide one.
+func timeoutContext() (context.Context, context.CancelFunc) {
+	if optTimeout > 0 {
+		return context.WithTimeout(context.Background(), optTimeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// untilDone invokes fn and returns true along with its error, unless ctx is
+// canceled and fn does not return shortly after, such as while it is blocked
+// reading a pipe, in which case it returns false along with the error of
+// ctx, and fn is abandoned while still running.
+func untilDone(ctx context.Context, fn func() error) (bool, error) {
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return true, err
+	case <-ctx.Done():
+	}
+	select {
+	case err := <-done:
+		return true, err
+	case <-time.After(cancelGrace):
+		return false, ctx.Err()
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// unitPattern matches a unit of measure expressed as a rate, such as ns/op,
+// B/op, allocs/op, and MB/s.
+var unitPattern = regexp.MustCompile(`^[A-Za-zµ%]+/[A-Za-z]+$`)
+
+// attachUnits returns fields with each unit of measure merged into the
+// numeric field that precedes it, separated by a single space, so a value
+// and its unit form a single cell, such as "1045 ns/op".
+func attachUnits(fields []string) []string {
+	attached := fields[:0:0]
+	for _, field := range fields {
+		if l := len(attached); l > 0 && unitPattern.MatchString(field) {
+			if _, ok := parseNumber(attached[l-1]); ok {
+				attached[l-1] += " " + field
+				continue
+			}
+		}
+		attached = append(attached, field)
+	}
+	return attached
+}
+
+// detachUnit returns field without the unit of measure attachUnits merged
+// into it, if any.
+func detachUnit(field string) string {
+	if i := strings.LastIndexByte(field, ' '); i >= 0 && unitPattern.MatchString(field[i+1:]) {
+		return field[:i]
+	}
+	return field
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// urlTimeout is the longest time allowed for connecting to the server of a
+// URL, and then for the server to begin responding. Reading the body of the
+// response, which may be a large table, is limited only by --timeout.
+const urlTimeout = 30 * time.Second
+
+// urlRedirects is the greatest number of redirects followed for a URL.
+const urlRedirects = 10
+
+// isURL returns true when path names a resource to be fetched over HTTP or
+// HTTPS rather than a file.
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+var httpClient = &http.Client{
+	Transport: urlTransport(),
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= urlRedirects {
+			return fmt.Errorf("stopped after %d redirects", urlRedirects)
+		}
+		if via[0].URL.Scheme == "https" && req.URL.Scheme != "https" {
+			return fmt.Errorf("refusing redirect from HTTPS to %s", req.URL.Scheme)
+		}
+		return nil
+	},
+}
+
+// urlTransport returns the transport of httpClient, which is the default
+// transport, honoring proxies from the environment, with the time to connect
+// and to receive the headers of a response limited to urlTimeout.
+func urlTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{Timeout: urlTimeout, KeepAlive: 30 * time.Second}).DialContext
+	t.TLSHandshakeTimeout = urlTimeout
+	t.ResponseHeaderTimeout = urlTimeout
+	return t
+}
+
+// openURL fetches url, returning its body when the server responds with
+// success. Canceling ctx abandons the request.
+func openURL(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return fetch(req, url)
+}
+
+// fetch sends req, returning the body of the response when the server
+// responds with success. The name identifies the resource in errors.
+func fetch(req *http.Request, name string) (io.ReadCloser, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("cannot fetch %q: %s", name, resp.Status)
+	}
+	return resp.Body, nil
+}
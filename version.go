@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// version and buildDate may be set when building a release, e.g.,
+//
+//	go build -ldflags "-X main.version=v1.2.3 -X main.buildDate=2024-05-01"
+//
+// Otherwise the version of the module, which 'go install' records, and the
+// date of the revision built are reported.
+var version, buildDate string
+
+// printVersion prints the version of the program, the revision of its source
+// it was built from, when it was built, and the version of Go that built it,
+// then exits.
+func printVersion() {
+	// Show version then exit, ignoring other possibly conflicting options
+	// when '--version' is given.
+	v, revision, date := version, "unknown", buildDate
+	var modified bool
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if v == "" {
+			v = bi.Main.Version
+		}
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.time":
+				if date == "" {
+					date = setting.Value
+				}
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+	}
+	if v == "" {
+		v = "(devel)"
+	}
+	if modified {
+		revision += " (modified)"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+
+	fmt.Printf("columnize %s\n", v)
+	fmt.Printf("revision:   %s\n", revision)
+	fmt.Printf("build date: %s\n", date)
+	fmt.Printf("go version: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	os.Exit(0)
+}
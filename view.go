@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Escape sequences for controlling the terminal while viewing a table.
+const (
+	termAltScreen  = "\x1b[?1049h" // switch to the alternate screen
+	termMainScreen = "\x1b[?1049l" // switch back to the main screen
+	termHideCursor = "\x1b[?25l"
+	termShowCursor = "\x1b[?25h"
+	termHome       = "\x1b[H"
+	termClearLine  = "\x1b[K"
+	termReverse    = "\x1b[7m"
+)
+
+const (
+	viewPanColumns  = 8 // number of columns panned by each key press
+	viewStatusLines = 1 // number of terminal rows used by the status line
+)
+
+// viewer is the state of the interactive table viewer.
+type viewer struct {
+	tty      *os.File
+	rows     [][]string   // header rows followed by body rows
+	heads    int          // number of header rows
+	hidden   map[int]bool // columns not shown
+	column   int          // index of the selected column
+	sorted   *sortKey     // column the body is sorted by, if any
+	text     []string     // rendered lines of the table
+	top      int          // index of the first body line shown
+	left     int          // number of columns panned to the right
+	search   string       // pattern of the most recent search
+	match    int          // index of the line matching search, or -1
+	typing   bool         // whether the search pattern is being typed
+	width    int          // number of columns of the terminal
+	height   int          // number of rows of the terminal
+	original [][]string   // body rows in input order
+}
+
+// viewFiles reads the table from files, or from standard input when there
+// are none, and lets the user explore it on their terminal until they quit.
+func viewFiles(ctx context.Context, files []string) error {
+	t, err := newTable()
+	if err != nil {
+		return err
+	}
+	_, err = untilDone(ctx, func() error {
+		return forEachFile(ctx, files, func(name string, r io.Reader, w io.Writer) error {
+			return t.read(r, ioutil.Discard, filenameField(name))
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// Key presses are read from the terminal, because standard input may be
+	// the table itself.
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("cannot open terminal: %s", err)
+	}
+	defer tty.Close()
+
+	restore, err := makeRaw(tty)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	v := &viewer{
+		tty:      tty,
+		rows:     append(append([][]string(nil), t.block...), t.lines...),
+		heads:    len(t.block),
+		hidden:   make(map[int]bool),
+		match:    -1,
+		original: append([][]string(nil), t.lines...),
+	}
+	io.WriteString(tty, termAltScreen+termHideCursor)
+	defer io.WriteString(tty, termShowCursor+termMainScreen)
+
+	v.layout()
+	key := make([]byte, 16)
+	for {
+		v.draw()
+		n, err := tty.Read(key)
+		if err != nil {
+			return err
+		}
+		// Escape sequences, such as those of arrow keys, arrive whole, while
+		// other keys typed or pasted faster than they are read are handled
+		// one at a time.
+		keys := []string{string(key[:n])}
+		if key[0] != '\x1b' {
+			keys = strings.Split(keys[0], "")
+		}
+		for _, k := range keys {
+			if !v.handle(k) {
+				return nil
+			}
+		}
+	}
+}
+
+// layout renders the visible columns of the table into lines of text.
+func (v *viewer) layout() {
+	var indexes []int
+	for i, n := 0, columnCount(v.rows); i < n; i++ {
+		if !v.hidden[i] {
+			indexes = append(indexes, i)
+		}
+	}
+	lines := make([][]string, len(v.rows))
+	for li, fields := range v.rows {
+		row := make([]string, 0, len(indexes))
+		for _, i := range indexes {
+			if i < len(fields) {
+				row = append(row, fields[i])
+			}
+		}
+		lines[li] = row
+	}
+
+	var bb bytes.Buffer
+	render(&bb, layout{lines: lines, heads: v.heads, uncounted: true})
+	v.text = strings.Split(strings.TrimSuffix(bb.String(), "\n"), "\n")
+}
+
+// body returns the number of terminal rows available for body lines.
+func (v *viewer) body() int {
+	if n := v.height - v.heads - viewStatusLines; n > 0 {
+		return n
+	}
+	return 1
+}
+
+// draw redraws the terminal: the header lines, the body lines scrolled to
+// the top line, and a status line.
+func (v *viewer) draw() {
+	v.width, v.height = windowSize(v.tty)
+	if v.width == 0 {
+		v.width, v.height = terminalWidth(), 24
+	}
+
+	var bb bytes.Buffer
+	bb.WriteString(termHome)
+	line := func(li int) {
+		if li < len(v.text) {
+			text := []rune(v.text[li])
+			if v.left < len(text) {
+				text = text[v.left:]
+			} else {
+				text = nil
+			}
+			if len(text) > v.width {
+				text = text[:v.width]
+			}
+			if li == v.match {
+				bb.WriteString(termReverse + string(text) + sgrReset)
+			} else {
+				bb.WriteString(string(text))
+			}
+		}
+		bb.WriteString(termClearLine + "\n")
+	}
+	for li := 0; li < v.heads && li < len(v.text); li++ {
+		line(li)
+	}
+	for row := 0; row < v.body(); row++ {
+		line(v.heads + v.top + row)
+	}
+	bb.WriteString(termReverse + v.status() + termClearLine + sgrReset)
+	v.tty.Write(bb.Bytes())
+}
+
+// status returns the text of the status line.
+func (v *viewer) status() string {
+	if v.typing {
+		return "/" + v.search
+	}
+	var label string
+	if v.heads > 0 && v.column < len(v.rows[v.heads-1]) {
+		label = " " + v.rows[v.heads-1][v.column]
+	}
+	rows := len(v.text) - v.heads
+	last := v.top + v.body()
+	if last > rows {
+		last = rows
+	}
+	status := fmt.Sprintf("rows %d-%d of %d  column %d%s", v.top+1, last, rows, v.column+1, label)
+	if v.hidden[v.column] {
+		status += " (hidden)"
+	}
+	if v.sorted != nil {
+		direction := "asc"
+		if v.sorted.descending {
+			direction = "desc"
+		}
+		status += fmt.Sprintf("  sorted by %d:%s", v.sorted.column+1, direction)
+	}
+	status += "  q:quit /:search [ ]:column s:sort x:hide u:unhide"
+	if len(status) > v.width {
+		status = status[:v.width]
+	}
+	return status
+}
+
+// handle updates the viewer for the pressed key, and returns false when the
+// user quits.
+func (v *viewer) handle(key string) bool {
+	if v.typing {
+		switch key {
+		case "\r", "\n":
+			v.typing = false
+		case "\x1b":
+			v.typing, v.search, v.match = false, "", -1
+		case "\x7f", "\b":
+			if v.search != "" {
+				v.search = v.search[:len(v.search)-1]
+				v.find(v.heads+v.top, 1)
+			}
+		default:
+			v.search += key
+			v.find(v.heads+v.top, 1)
+		}
+		return true
+	}
+
+	rows := len(v.text) - v.heads
+	switch key {
+	case "q", "\x03":
+		return false
+	case "j", "\x1b[B", "\r":
+		v.top++
+	case "k", "\x1b[A":
+		v.top--
+	case " ", "\x1b[6~":
+		v.top += v.body()
+	case "b", "\x1b[5~":
+		v.top -= v.body()
+	case "g", "\x1b[H":
+		v.top = 0
+	case "G", "\x1b[F":
+		v.top = rows - v.body()
+	case "l", "\x1b[C":
+		v.left += viewPanColumns
+	case "h", "\x1b[D":
+		if v.left -= viewPanColumns; v.left < 0 {
+			v.left = 0
+		}
+	case "]":
+		if v.column < columnCount(v.rows)-1 {
+			v.column++
+		}
+	case "[":
+		if v.column > 0 {
+			v.column--
+		}
+	case "/":
+		v.typing, v.search = true, ""
+	case "n":
+		v.find(v.match+1, 1)
+	case "N":
+		v.find(v.match-1, -1)
+	case "s":
+		key := sortKey{column: v.column}
+		if v.sorted != nil && v.sorted.column == v.column {
+			key.descending = !v.sorted.descending
+		}
+		v.sorted = &key
+		copy(v.rows[v.heads:], v.original)
+		sortRows(v.rows[v.heads:], []sortKey{key})
+		v.match = -1
+		v.layout()
+	case "x":
+		v.hidden[v.column] = true
+		v.match = -1
+		v.layout()
+	case "u":
+		v.hidden = make(map[int]bool)
+		v.match = -1
+		v.layout()
+	}
+	v.scroll()
+	return true
+}
+
+// find moves the match to the first body line at or after, when step is
+// positive, or at or before, when step is negative, the line with index from
+// that contains the search pattern, without regard to case, and scrolls to
+// show it.
+func (v *viewer) find(from, step int) {
+	if v.search == "" {
+		return
+	}
+	if from < v.heads {
+		from = v.heads
+	}
+	pattern := strings.ToLower(v.search)
+	for li := from; li >= v.heads && li < len(v.text); li += step {
+		if strings.Contains(strings.ToLower(v.text[li]), pattern) {
+			v.match = li
+			if li < v.heads+v.top || li >= v.heads+v.top+v.body() {
+				v.top = li - v.heads
+			}
+			v.scroll()
+			return
+		}
+	}
+}
+
+// scroll keeps the top line within the body lines.
+func (v *viewer) scroll() {
+	if max := len(v.text) - v.heads - v.body(); v.top > max {
+		v.top = max
+	}
+	if v.top < 0 {
+		v.top = 0
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// expandDirectories returns files with each directory among them replaced by
+// the regular files found by walking it, in lexical order, keeping only
+// those whose base names match pattern, when it is not empty.
+func expandDirectories(files []string, pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("cannot parse glob pattern: %q", pattern)
+	}
+
+	var expanded []string
+	for _, file := range files {
+		if _, _, ok := splitArchive(file); ok || file == "-" || isURL(file) || isCloudURI(file) {
+			expanded = append(expanded, file)
+			continue
+		}
+		if fi, err := os.Stat(file); err != nil || !fi.IsDir() {
+			expanded = append(expanded, file) // reported when opened
+			continue
+		}
+		err := filepath.Walk(file, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			if pattern != "" {
+				if ok, _ := filepath.Match(pattern, info.Name()); !ok {
+					return nil
+				}
+			}
+			expanded = append(expanded, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// defaultWatchInterval is the time between redraws when watching input files
+// without an interval.
+const defaultWatchInterval = 2 * time.Second
+
+// watchPoll is the time between checks for changes to watched files.
+const watchPoll = 250 * time.Millisecond
+
+// termClearScreen moves the cursor to the top of the screen and clears it.
+const termClearScreen = "\x1b[H\x1b[2J"
+
+var errWatchStdin = errors.New("cannot watch standard input, because it cannot be read again")
+
+// watch formats files, or the output of the user's command, then does so
+// again whenever any of the files changes, and after each interval elapses,
+// clearing the screen before each redraw. It only returns when files cannot
+// be watched, ctx is canceled, or the user interrupts it.
+func watch(ctx context.Context, files []string, interval time.Duration) error {
+	if len(files) == 0 && optExec == "" {
+		return errWatchStdin
+	}
+	for _, file := range files {
+		if file == "-" {
+			return errWatchStdin
+		}
+	}
+
+	headerLines := optHeaderLines // each redraw has its own header lines
+	var stamps []time.Time
+	var drawn time.Time
+
+	for {
+		if changed := fileStamps(files); !equalStamps(changed, stamps) || time.Since(drawn) >= interval {
+			stamps, drawn = changed, time.Now()
+			optHeaderLines = headerLines
+			io.WriteString(output, termClearScreen)
+			err := run(ctx)
+			output.Flush()
+			if err != nil {
+				if _, ok := err.(*exec.ExitError); !ok {
+					log.Warning("%s", err)
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-interrupted:
+			return nil
+		case <-time.After(watchPoll):
+		}
+	}
+}
+
+// fileStamps returns the modification time of each of files, or the zero
+// time for those that cannot be found.
+func fileStamps(files []string) []time.Time {
+	stamps := make([]time.Time, len(files))
+	for i, file := range files {
+		if fi, err := os.Stat(file); err == nil {
+			stamps[i] = fi.ModTime()
+		}
+	}
+	return stamps
+}
+
+// equalStamps returns true when a and b hold the same times.
+func equalStamps(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}